@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	MIMEProblemJSON = "application/problem+json"
+	DefaultType     = "about:blank"
+)
+
+// Problem is a RFC 9457 problem details document
+type Problem struct {
+	Type     string       `json:"type" description:"URI reference identifying the problem type"`
+	Title    string       `json:"title" description:"Short summary of the problem type"`
+	Status   int          `json:"status" description:"HTTP status code"`
+	Detail   string       `json:"detail,omitempty" description:"Explanation specific to this occurrence"`
+	Instance string       `json:"instance,omitempty" description:"URI reference of this occurrence"`
+	Errors   []FieldError `json:"errors,omitempty" description:"Validation errors of the request"`
+}
+
+// FieldError describe a single failed validation rule
+type FieldError struct {
+	Field string `json:"field" description:"JSON path of the invalid field"`
+	Rule  string `json:"rule" description:"Validation rule that failed"`
+	Param string `json:"param,omitempty" description:"Parameter of the rule"`
+}
+
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   DefaultType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+func (p *Problem) StatusCode() int {
+	return p.Status
+}
+
+// FromError build a problem from err, validation errors are listed in Errors
+func FromError(err error, status int) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		p = New(status, "request validation failed")
+		for _, fe := range validationErrors {
+			p.Errors = append(p.Errors, FieldError{
+				Field: fieldPath(fe.Namespace()),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+			})
+		}
+		return p
+	}
+
+	// don't leak internal errors
+	if status >= http.StatusInternalServerError {
+		return New(status, "")
+	}
+	return New(status, err.Error())
+}
+
+// Write send p as application/problem+json and abort the request
+func Write(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", MIMEProblemJSON)
+	c.Render(p.Status, render.JSON{Data: p})
+	c.Abort()
+}
+
+// ErrorHandler is the default error handler of routers, it writes err as a problem document
+func ErrorHandler(c *gin.Context, err error, status int) {
+	Write(c, FromError(err, status))
+}
+
+// fieldPath strip the root struct name, e.g. "Request.items[0].name" -> "items[0].name",
+// namespaces of slice and map models have no root, e.g. "[0].name"
+func fieldPath(namespace string) string {
+	if strings.HasPrefix(namespace, "[") {
+		return namespace
+	}
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
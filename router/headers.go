@@ -0,0 +1,65 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WriteHeaders set the response headers from the fields tagged header of a struct, the same
+// struct documents them through ResponseItem.HeaderModel. Nil pointers, empty strings and zero
+// fields tagged omitempty are left out, slices are written as repeated headers
+func WriteHeaders(c *gin.Context, headers interface{}) {
+	value := reflect.Indirect(reflect.ValueOf(headers))
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	type_ := value.Type()
+	for i := 0; i < type_.NumField(); i++ {
+		field := type_.Field(i)
+		fieldValue := value.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("header"), ",")
+		if field.Anonymous && name == "" {
+			WriteHeaders(c, fieldValue.Interface())
+			continue
+		}
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		if fieldValue.IsZero() && strings.Contains(","+options+",", ",omitempty,") {
+			continue
+		}
+		for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Ptr {
+			continue
+		}
+
+		header := c.Writer.Header()
+		header.Del(name)
+		if fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() != reflect.Uint8 {
+			for j := 0; j < fieldValue.Len(); j++ {
+				header.Add(name, formatHeader(fieldValue.Index(j)))
+			}
+		} else if val := formatHeader(fieldValue); val != "" {
+			header.Set(name, val)
+		}
+	}
+}
+
+func formatHeader(value reflect.Value) string {
+	switch val := value.Interface().(type) {
+	case time.Time:
+		return val.UTC().Format(http.TimeFormat)
+	case []byte:
+		return string(val)
+	case fmt.Stringer:
+		return val.String()
+	}
+	return fmt.Sprint(value.Interface())
+}
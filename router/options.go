@@ -1,6 +1,8 @@
 package router
 
 import (
+	"sync"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sparkle-technologies/swagger_gin/security"
 )
@@ -14,6 +16,8 @@ var (
 
 type Option func(router *Router)
 
+// Security add securities that must all authorize the request, they are documented as a single
+// requirement combining their schemes, use security.AnyOf for alternatives
 func Security(securities ...security.ISecurity) Option {
 	return func(router *Router) {
 		router.Securities = append(router.Securities, securities...)
@@ -80,13 +84,58 @@ func Exclude() Option {
 func ContentType(contentType string, contentTypeType ContentTypeType) Option {
 	return func(router *Router) {
 		if contentTypeType == ContentTypeRequest {
-			router.RequestContentType = contentType
+			router.RequestContentTypes = []string{contentType}
 		} else {
-			router.ResponseContentType = contentType
+			router.ResponseContentTypes = []string{contentType}
 		}
 	}
 }
 
+// RequestBody force binding and documenting the request body on or off
+func RequestBody(enabled bool) Option {
+	return func(router *Router) {
+		router.RequestBody = &enabled
+	}
+}
+
+// ModelPool recycle request models through a sync.Pool
+func ModelPool() Option {
+	return func(router *Router) {
+		router.ModelPool = &sync.Pool{}
+	}
+}
+
+// RequestContentTypes Set the request contentTypes accepted by the route
+func RequestContentTypes(contentTypes ...string) Option {
+	return func(router *Router) {
+		router.RequestContentTypes = contentTypes
+	}
+}
+
+// ResponseContentTypes Set the response contentTypes the route can produce
+func ResponseContentTypes(contentTypes ...string) Option {
+	return func(router *Router) {
+		router.ResponseContentTypes = contentTypes
+	}
+}
+
+// ReadOnly strip or reject the fields tagged openapi:"readonly" sent in the request body
+func ReadOnly(mode ReadOnlyMode) Option {
+	return func(router *Router) {
+		router.ReadOnly = mode
+	}
+}
+
+// Scopes require the OAuth2 scopes on the route's tokens, tokens lacking one are rejected with 403,
+// the scopes are documented in the operation's oauth2 and openIdConnect security requirements.
+// Every alternative of the route's securities needs a security.ScopeChecker, e.g. an OAuth2, an
+// OpenID or a Bearer with a Verifier, or the route panics when initialized
+func Scopes(scopes ...string) Option {
+	return func(router *Router) {
+		router.Scopes = append(router.Scopes, scopes...)
+	}
+}
+
 // error handler
 func ErrorHandler(handler ErrorHandlerFunc) Option {
 	return func(router *Router) {
@@ -1,15 +1,44 @@
 package router
 
 import (
+	"errors"
+	"mime"
 	"net/http"
+	"strings"
 	_ "unsafe"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/jinzhu/copier"
 )
 
 var Query = queryBinding{}
 
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// Bindings map request media types to the binding of the body
+var Bindings = map[string]binding.Binding{
+	binding.MIMEJSON:              JSON,
+	binding.MIMEXML:               binding.XML,
+	binding.MIMEXML2:              binding.XML,
+	binding.MIMEPOSTForm:          binding.Form,
+	binding.MIMEMultipartPOSTForm: binding.FormMultipart,
+	binding.MIMEYAML:              binding.YAML,
+	"application/yaml":            binding.YAML,
+	binding.MIMEPROTOBUF:          binding.ProtoBuf,
+	binding.MIMEMSGPACK:           binding.MsgPack,
+	binding.MIMEMSGPACK2:          binding.MsgPack,
+}
+
+// ParseMediaType strip the parameters of a Content-Type, e.g. "application/json; charset=utf-8" -> "application/json"
+func ParseMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(mediaType)
+}
+
 func CookiesParser(c *gin.Context, model interface{}) error {
 	params := make(map[string][]string)
 	for _, cookie := range c.Request.Cookies() {
@@ -0,0 +1,303 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Polymorphic describe an interface whose implementation is selected by a discriminator property
+type Polymorphic struct {
+	Interface     reflect.Type
+	Discriminator string
+	Types         map[string]reflect.Type
+}
+
+var (
+	polymorphicsMu sync.RWMutex
+	polymorphics   = make(map[reflect.Type]*Polymorphic)
+)
+
+// RegisterPolymorphic register the implementations of interface I by their discriminator value,
+// e.g. RegisterPolymorphic[PaymentMethod]("type", map[string]PaymentMethod{"card": Card{}, "iban": &Iban{}})
+func RegisterPolymorphic[I any](discriminator string, implementations map[string]I) *Polymorphic {
+	iface := reflect.TypeOf((*I)(nil)).Elem()
+	if iface.Kind() != reflect.Interface {
+		panic(fmt.Errorf("'%s' is not an interface", iface))
+	}
+	p := &Polymorphic{
+		Interface:     iface,
+		Discriminator: discriminator,
+		Types:         make(map[string]reflect.Type, len(implementations)),
+	}
+	for value, implementation := range implementations {
+		p.Types[value] = reflect.TypeOf(implementation)
+	}
+	polymorphicsMu.Lock()
+	defer polymorphicsMu.Unlock()
+	polymorphics[iface] = p
+	return p
+}
+
+// GetPolymorphic return the registered Polymorphic of an interface type
+func GetPolymorphic(type_ reflect.Type) (*Polymorphic, bool) {
+	polymorphicsMu.RLock()
+	defer polymorphicsMu.RUnlock()
+	p, ok := polymorphics[type_]
+	return p, ok
+}
+
+var JSON = jsonBinding{}
+
+// jsonBinding decode JSON bodies like binding.JSON, interface fields of registered
+// Polymorphic types are decoded into the implementation selected by the discriminator
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (b jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return b.BindBody(body, obj)
+}
+
+func (jsonBinding) BindBody(body []byte, obj interface{}) (err error) {
+	value := reflect.ValueOf(obj)
+	polymorphic := containsPolymorphic(value.Type(), make(map[reflect.Type]bool))
+	if polymorphic {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var raw interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+		if err := preparePolymorphic(value, raw); err != nil {
+			return err
+		}
+		// the maps decoded by preparePolymorphic are emptied from the body
+		if body, err = json.Marshal(raw); err != nil {
+			return err
+		}
+	}
+
+	if err = binding.JSON.BindBody(body, obj); err != nil {
+		return err
+	}
+
+	if polymorphic {
+		unwrapPolymorphic(value)
+	}
+	return nil
+}
+
+func containsPolymorphic(type_ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[type_] {
+		return false
+	}
+	seen[type_] = true
+
+	switch type_.Kind() {
+	case reflect.Interface:
+		_, ok := GetPolymorphic(type_)
+		return ok
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return containsPolymorphic(type_.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < type_.NumField(); i++ {
+			if containsPolymorphic(type_.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// preparePolymorphic walk the decoded JSON along with v and set registered interfaces to
+// a pointer of their implementation, which encoding/json then decodes into. encoding/json
+// decodes map values into zero values, so maps are decoded here and emptied from raw
+func preparePolymorphic(v reflect.Value, raw interface{}) error {
+	if raw == nil {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return preparePolymorphic(v.Elem(), raw)
+	case reflect.Interface:
+		p, ok := GetPolymorphic(v.Type())
+		obj, isObject := raw.(map[string]interface{})
+		if !ok || !isObject || !v.CanSet() {
+			return nil
+		}
+		discriminator, _ := obj[p.Discriminator].(string)
+		type_, ok := p.Types[discriminator]
+		if !ok {
+			return fmt.Errorf("invalid %s '%s' of %s", p.Discriminator, discriminator, p.Interface)
+		}
+		if type_.Kind() == reflect.Ptr {
+			type_ = type_.Elem()
+		}
+		concrete := reflect.New(type_)
+		if err := preparePolymorphic(concrete.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(concrete)
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		type_ := v.Type()
+		for i := 0; i < type_.NumField(); i++ {
+			field := type_.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || (!field.IsExported() && !field.Anonymous) {
+				continue
+			}
+			if field.Anonymous && name == "" {
+				// promoted fields share the object of the outer struct
+				if err := preparePolymorphic(v.Field(i), raw); err != nil {
+					return err
+				}
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if err := preparePolymorphic(v.Field(i), jsonField(obj, name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]interface{})
+		if !ok || !containsPolymorphic(v.Type(), make(map[reflect.Type]bool)) {
+			return nil
+		}
+		if v.Kind() == reflect.Slice && v.Len() < len(arr) {
+			v.Set(reflect.MakeSlice(v.Type(), len(arr), len(arr)))
+		}
+		for i := 0; i < len(arr) && i < v.Len(); i++ {
+			if err := preparePolymorphic(v.Index(i), arr[i]); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		type_ := v.Type()
+		if !ok || type_.Key().Kind() != reflect.String || !v.CanSet() ||
+			!containsPolymorphic(type_, make(map[reflect.Type]bool)) {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(type_, len(obj)))
+		}
+		for key, val := range obj {
+			elem := reflect.New(type_.Elem())
+			if err := preparePolymorphic(elem.Elem(), val); err != nil {
+				return err
+			}
+			data, err := json.Marshal(val)
+			if err != nil {
+				return err
+			}
+			if err := decodeJSON(data, elem.Interface()); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(type_.Key()), elem.Elem())
+			delete(obj, key)
+		}
+	}
+	return nil
+}
+
+// decodeJSON decode data into obj with the decoder options of binding.JSON
+func decodeJSON(data []byte, obj interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if binding.EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	if binding.EnableDecoderDisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(obj)
+}
+
+// unwrapPolymorphic replace the pointers set by preparePolymorphic with values
+// for implementations registered as values
+func unwrapPolymorphic(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			unwrapPolymorphic(v.Elem())
+		}
+	case reflect.Interface:
+		p, ok := GetPolymorphic(v.Type())
+		if !ok || v.IsNil() || v.Elem().Kind() != reflect.Ptr || v.Elem().IsNil() {
+			return
+		}
+		concrete := v.Elem().Elem()
+		unwrapPolymorphic(concrete)
+		for _, type_ := range p.Types {
+			if type_ == concrete.Type() && v.CanSet() {
+				v.Set(concrete)
+				return
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() || v.Type().Field(i).Anonymous {
+				unwrapPolymorphic(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			unwrapPolymorphic(v.Index(i))
+		}
+	case reflect.Map:
+		if !containsPolymorphic(v.Type(), make(map[reflect.Type]bool)) {
+			return
+		}
+		// map values aren't addressable, unwrap a copy and store it back
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			unwrapPolymorphic(elem)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+}
+
+// jsonField lookup a key like encoding/json, preferring an exact match over a case-insensitive one
+func jsonField(obj map[string]interface{}, name string) interface{} {
+	if val, ok := obj[name]; ok {
+		return val
+	}
+	for key, val := range obj {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ReadOnlyMode tell BindModel what to do with fields tagged openapi:"readonly" sent in the request body
+type ReadOnlyMode int
+
+const (
+	// ReadOnlyBind bind readOnly fields like any other field
+	ReadOnlyBind ReadOnlyMode = iota
+	// ReadOnlyStrip reset readOnly fields to their zero value
+	ReadOnlyStrip
+	// ReadOnlyReject fail the request with 400 when a readOnly field is set
+	ReadOnlyReject
+)
+
+var ErrReadOnlyField = errors.New("read-only fields can't be set")
+
+// checkReadOnly strip or reject the readOnly fields set in model according to the route's ReadOnly mode
+func (router *Router) checkReadOnly(model interface{}) error {
+	if router.ReadOnly == ReadOnlyBind {
+		return nil
+	}
+	fields := readOnlyFields(reflect.ValueOf(model), "", router.ReadOnly == ReadOnlyStrip)
+	if len(fields) > 0 && router.ReadOnly == ReadOnlyReject {
+		return fmt.Errorf("%w: %s", ErrReadOnlyField, strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// readOnlyFields list the paths of the non-zero readOnly fields of v, zeroing them when strip is set
+func readOnlyFields(v reflect.Value, path string, strip bool) []string {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return readOnlyFields(v.Elem(), path, strip)
+	case reflect.Slice, reflect.Array:
+		var fields []string
+		for i := 0; i < v.Len(); i++ {
+			fields = append(fields, readOnlyFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), strip)...)
+		}
+		return fields
+	case reflect.Struct:
+		var fields []string
+		type_ := v.Type()
+		for i := 0; i < type_.NumField(); i++ {
+			field := type_.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || IsParameter(field) {
+				continue
+			}
+			fieldPath := path
+			if !field.Anonymous || name != "" {
+				if name == "" {
+					name = field.Name
+				}
+				fieldPath = strings.TrimPrefix(path+"."+name, ".")
+			}
+			if !HasOpenAPIOption(field, "readonly") {
+				fields = append(fields, readOnlyFields(v.Field(i), fieldPath, strip)...)
+				continue
+			}
+			if !v.Field(i).IsZero() {
+				fields = append(fields, fieldPath)
+				if strip && v.Field(i).CanSet() {
+					v.Field(i).SetZero()
+				}
+			}
+		}
+		return fields
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// Renderers map response media types to the render of the body
+var Renderers = map[string]func(obj any) render.Render{
+	binding.MIMEJSON: func(obj any) render.Render {
+		return render.JSON{Data: obj}
+	},
+	binding.MIMEXML: func(obj any) render.Render {
+		return render.XML{Data: obj}
+	},
+	binding.MIMEXML2: func(obj any) render.Render {
+		return render.XML{Data: obj}
+	},
+	binding.MIMEYAML: func(obj any) render.Render {
+		return render.YAML{Data: obj}
+	},
+	"application/yaml": func(obj any) render.Render {
+		return render.YAML{Data: obj}
+	},
+	binding.MIMEPROTOBUF: func(obj any) render.Render {
+		return render.ProtoBuf{Data: obj}
+	},
+	binding.MIMEMSGPACK: func(obj any) render.Render {
+		return render.MsgPack{Data: obj}
+	},
+	binding.MIMEMSGPACK2: func(obj any) render.Render {
+		return render.MsgPack{Data: obj}
+	},
+}
+
+// Render write obj in the first of the route's ResponseContentTypes accepted by the client,
+// requests accepting none of them, or none obj can be encoded as, get 406
+func (router *Router) Render(c *gin.Context, status int, obj any) {
+	var offers []string
+	for _, offer := range router.GetResponseContentTypes() {
+		if renderable(ParseMediaType(offer), obj) {
+			offers = append(offers, offer)
+		}
+	}
+	mediaType := Negotiate(c.GetHeader("Accept"), offers)
+	renderer, ok := Renderers[ParseMediaType(mediaType)]
+	if !ok {
+		router.HandleError(c, ErrNotAcceptable, http.StatusNotAcceptable)
+		return
+	}
+	c.Header("Content-Type", mediaType)
+	c.Render(status, renderer(obj))
+}
+
+// renderable report whether obj can be rendered as mediaType, render.ProtoBuf panics on values
+// that aren't proto messages
+func renderable(mediaType string, obj any) bool {
+	if _, ok := Renderers[mediaType]; !ok {
+		return false
+	}
+	if mediaType == binding.MIMEPROTOBUF {
+		_, ok := obj.(proto.Message)
+		return ok
+	}
+	return true
+}
+
+// Render write obj with the Router serving the request, JSON is used outside of routers
+func Render(c *gin.Context, status int, obj any) {
+	if r, ok := c.Get(RouterKey); ok {
+		r.(*Router).Render(c, status, obj)
+		return
+	}
+	c.JSON(status, obj)
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// Negotiate return the offer preferred by the Accept header, or "" if none is acceptable. Offers
+// get the quality of the most specific range matching them, so that q=0 ranges exclude them, and
+// ties go to the offer matched by the more specific range, then to the first offer
+func Negotiate(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: strings.ToLower(mediaType), q: q})
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		mediaType := ParseMediaType(offer)
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := rangeSpecificity(r.mediaType); s > specificity && matchMediaType(r.mediaType, mediaType) {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+// rangeSpecificity rank */* below type/* below a full media type
+func rangeSpecificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	}
+	return 2
+}
+
+func matchMediaType(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(mediaType, prefix)
+	}
+	return false
+}
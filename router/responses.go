@@ -2,10 +2,21 @@ package router
 
 import "github.com/getkin/kin-openapi/openapi3"
 
+// Response map a status code, or "default", to the response it documents
 type Response map[string]ResponseItem
 
 type ResponseItem struct {
 	Description string
 	Model       interface{}
 	Headers     openapi3.Headers
+	// HeaderModel document the response headers from the header tags of a struct, see WriteHeaders
+	HeaderModel interface{}
+	// Content document a model per media type, it takes precedence over Model and the route's ResponseContentTypes
+	Content map[string]MediaType
+}
+
+// MediaType describe the model and named examples of a response media type
+type MediaType struct {
+	Model    interface{}
+	Examples map[string]interface{}
 }
@@ -2,17 +2,19 @@ package router
 
 import (
 	"container/list"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/mcuadros/go-defaults"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"github.com/jinzhu/copier"
+	"github.com/sparkle-technologies/swagger_gin/problem"
 	"github.com/sparkle-technologies/swagger_gin/security"
 )
 
@@ -20,112 +22,266 @@ type Model any
 type ErrorHandlerFunc func(ctx *gin.Context, err error, status int)
 
 type Router struct {
-	Handlers            *list.List
-	Path                string
-	Method              string
-	Summary             string
-	Description         string
-	Deprecated          bool
-	RequestContentType  string
+	Handlers             *list.List
+	Path                 string
+	Method               string
+	Summary              string
+	Description          string
+	Deprecated           bool
+	RequestContentTypes  []string
+	ResponseContentTypes []string
+	// Deprecated: use RequestContentTypes, it is only read when RequestContentTypes is empty
+	RequestContentType string
+	// Deprecated: use ResponseContentTypes, it is only read when ResponseContentTypes is empty
 	ResponseContentType string
 	Tags                []string
 	API                 gin.HandlerFunc
 	Model               Model
 	OperationID         string
 	Exclude             bool
-	Securities          []security.ISecurity
-	Response            Response
-	ErrorHandler        ErrorHandlerFunc
+	// Securities must all authorize the request, like a security.AllOf
+	Securities   []security.ISecurity
+	Response     Response
+	ErrorHandler ErrorHandlerFunc
+	ModelPool    *sync.Pool
+	RequestBody  *bool
+	ReadOnly     ReadOnlyMode
+	Scopes       []string
 }
 
-var Validate = validator.New()
+var Validate = newValidate()
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+	// report fields by the name they are bound from rather than the go field name
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, key := range []string{"json", "form", "query", "uri", "header", "cookie"} {
+			name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return ""
+	})
+	return v
+}
+
+const (
+	// ModelKey is the gin.Context key holding the request model bound by BindModel
+	ModelKey = "swagger_gin_model"
+	// RouterKey is the gin.Context key holding the Router serving the request
+	RouterKey = "swagger_gin_router"
+)
+
+// defaultsOnce guard the lazy, unsynchronized initialization of the go-defaults filler
+var defaultsOnce sync.Once
 
 func (router *Router) BindModel(req interface{}) gin.HandlerFunc {
+	type_ := reflect.TypeOf(req).Elem()
+	defaultsOnce.Do(func() {
+		defaults.SetDefaults(&struct{}{})
+	})
 	return func(c *gin.Context) {
+		model := router.newModel(type_)
+		// only structs have fields bound from parameters, other models are bound from the body
+		isStruct := type_.Kind() == reflect.Struct
 		bindErr := func() (err error) {
-			model := reflect.New(reflect.TypeOf(req).Elem()).Interface()
-			if err = c.ShouldBindHeader(model); err != nil {
-				return
-			}
+			if isStruct {
+				if err = c.ShouldBindHeader(model); err != nil {
+					return
+				}
 
-			if err = CookiesParser(c, model); err != nil {
-				return
-			}
+				if err = CookiesParser(c, model); err != nil {
+					return
+				}
 
-			if err = c.ShouldBindWith(model, Query); err != nil {
-				return
+				if err = c.ShouldBindWith(model, Query); err != nil {
+					return
+				}
 			}
 
-			if c.Request.Method == http.MethodPost || c.Request.Method == http.MethodPut {
-				switch c.Request.Header.Get("Content-Type") {
-				case binding.MIMEMultipartPOSTForm:
-					if err = c.ShouldBindWith(model, binding.FormMultipart); err != nil {
-						return
-					}
-				case binding.MIMEJSON:
-					if err = c.ShouldBindWith(model, binding.JSON); err != nil {
-						return
-					}
-				case binding.MIMEXML:
-					if err = c.ShouldBindWith(model, binding.XML); err != nil {
-						return
-					}
-				case binding.MIMEPOSTForm:
-					if err = c.ShouldBindWith(model, binding.Form); err != nil {
-						return
-					}
-				case binding.MIMEYAML:
-					if err = c.ShouldBindWith(model, binding.YAML); err != nil {
-						return
-					}
-				case binding.MIMEPROTOBUF:
-					if err = c.ShouldBindWith(model, binding.ProtoBuf); err != nil {
-						return
-					}
-				case binding.MIMEMSGPACK:
-					if err = c.ShouldBindWith(model, binding.MsgPack); err != nil {
-						return
-					}
+			if router.HasBody(c.Request.Method) {
+				if err = router.bindBody(c, model); err != nil {
+					return
+				}
+				if err = router.checkReadOnly(model); err != nil {
+					return
 				}
 			}
 
-			if err = c.ShouldBindUri(model); err != nil {
-				return
-			}
+			if isStruct {
+				if err = c.ShouldBindUri(model); err != nil {
+					return
+				}
 
-			defaults.SetDefaults(model)
+				defaults.SetDefaults(model)
+			}
 			defer func() {
 				if rec := recover(); rec != nil {
 					err = fmt.Errorf("%v", rec)
 				}
 			}()
 
-			if err = Validate.Struct(model); err != nil {
-				return err
-			}
-
-			if err = copier.Copy(req, model); err != nil {
-				return err
-			}
-
-			return nil
+			return validateModel(model)
 		}()
 
 		if bindErr != nil {
-			if router.ErrorHandler != nil {
-				router.ErrorHandler(c, bindErr, http.StatusBadRequest)
-				c.Abort()
-			} else {
-				log.Panic(bindErr)
+			router.releaseModel(c, model)
+			status := http.StatusBadRequest
+			if errors.Is(bindErr, ErrUnsupportedMediaType) {
+				status = http.StatusUnsupportedMediaType
 			}
+			router.HandleError(c, bindErr, status)
+			return
 		}
 
+		c.Set(ModelKey, model)
 		c.Next()
+		router.releaseModel(c, model)
+	}
+}
+
+// validateModel validate struct models, and the elements of slice and map models
+func validateModel(model interface{}) error {
+	value := reflect.ValueOf(model).Elem()
+	switch value.Kind() {
+	case reflect.Struct:
+		return Validate.Struct(model)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return Validate.Var(value.Interface(), "dive")
 	}
+	return nil
+}
+
+// HasBody report whether requests of method carry a body for this route,
+// POST, PUT, PATCH and DELETE do unless the route sets RequestBody
+func (router *Router) HasBody(method string) bool {
+	if router.RequestBody != nil {
+		return *router.RequestBody
+	}
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// bindBody bind the request body with the binding of its media type,
+// media types not accepted by the route are rejected with ErrUnsupportedMediaType
+func (router *Router) bindBody(c *gin.Context, model interface{}) error {
+	contentType := c.Request.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType := ParseMediaType(contentType)
+	if !router.AcceptsContentType(mediaType) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+	b, ok := Bindings[mediaType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+	return c.ShouldBindWith(model, b)
+}
+
+// GetRequestContentTypes return the route's RequestContentTypes, or the deprecated RequestContentType
+func (router *Router) GetRequestContentTypes() []string {
+	if len(router.RequestContentTypes) == 0 && router.RequestContentType != "" {
+		return []string{router.RequestContentType}
+	}
+	return router.RequestContentTypes
+}
+
+// GetResponseContentTypes return the route's ResponseContentTypes, or the deprecated
+// ResponseContentType, routes without any produce JSON
+func (router *Router) GetResponseContentTypes() []string {
+	if len(router.ResponseContentTypes) > 0 {
+		return router.ResponseContentTypes
+	}
+	if router.ResponseContentType != "" {
+		return []string{router.ResponseContentType}
+	}
+	return []string{binding.MIMEJSON}
+}
+
+// AcceptsContentType report whether mediaType is one of the route's RequestContentTypes,
+// routes without RequestContentTypes accept every media type in Bindings
+func (router *Router) AcceptsContentType(mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	contentTypes := router.GetRequestContentTypes()
+	if len(contentTypes) == 0 {
+		return true
+	}
+	for _, contentType := range contentTypes {
+		if ParseMediaType(contentType) == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleError pass err to the route's ErrorHandler and abort the request,
+// routes without ErrorHandler respond with a problem document
+func (router *Router) HandleError(c *gin.Context, err error, status int) {
+	if router.ErrorHandler != nil {
+		router.ErrorHandler(c, err, status)
+	} else {
+		problem.ErrorHandler(c, err, status)
+	}
+	c.Abort()
+}
+
+// newModel returns a fresh *T for the current request, reusing a pooled one when available
+func (router *Router) newModel(type_ reflect.Type) interface{} {
+	if router.ModelPool != nil {
+		if model := router.ModelPool.Get(); model != nil {
+			return model
+		}
+	}
+	return reflect.New(type_).Interface()
+}
+
+// releaseModel zeroes the model and gives it back to the pool once the request is done, it is
+// unpublished from the context first so that outer middlewares can't read it once reused
+func (router *Router) releaseModel(c *gin.Context, model interface{}) {
+	if router.ModelPool == nil {
+		return
+	}
+	if current, ok := c.Get(ModelKey); ok && current == model {
+		c.Set(ModelKey, nil)
+	}
+	reflect.ValueOf(model).Elem().SetZero()
+	router.ModelPool.Put(model)
+}
+
+// GetModel returns the request model bound for the current request
+func GetModel[T Model](c *gin.Context) (T, bool) {
+	if model, ok := c.Get(ModelKey); ok {
+		if req, ok := model.(*T); ok {
+			return *req, true
+		}
+	}
+	var zero T
+	return zero, false
 }
 
 func (router *Router) GetHandlers() []gin.HandlerFunc {
-	var handlers []gin.HandlerFunc
+	if len(router.Scopes) > 0 && !security.ChecksScopes(security.AllOf(router.Securities...)) {
+		// the scopes would go unchecked for the requests authorized without a verified token
+		panic(fmt.Errorf("route %s %s requires scopes but can be authorized by a security that doesn't check them",
+			router.Method, router.Path))
+	}
+	handlers := []gin.HandlerFunc{func(c *gin.Context) {
+		c.Set(RouterKey, router)
+		if len(router.Scopes) > 0 {
+			c.Set(security.ScopesKey, router.Scopes)
+		}
+	}}
 	for _, s := range router.Securities {
 		handlers = append(handlers, s.Authorize)
 	}
@@ -159,11 +315,10 @@ func New[T Model, F func(c *gin.Context, req T)](f F, options ...Option) *Router
 		Handlers: list.New(),
 		Response: make(Response),
 		API: func(ctx *gin.Context) {
-			f(ctx, model)
+			req, _ := GetModel[T](ctx)
+			f(ctx, req)
 		},
-		Model:               model,
-		RequestContentType:  "application/json",
-		ResponseContentType: "application/json",
+		Model: model,
 	}
 
 	for _, option := range options {
@@ -174,6 +329,49 @@ func New[T Model, F func(c *gin.Context, req T)](f F, options ...Option) *Router
 	return r
 }
 
+// StatusCoder is implemented by errors that carry their own http status
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// NewTyped create a router whose handler returns the response model and an error,
+// the response is rendered by Render and documented as the 200 response
+func NewTyped[T Model, R any](f func(c *gin.Context, req T) (R, error), options ...Option) *Router {
+	var r *Router
+	r = New(func(c *gin.Context, req T) {
+		resp, err := f(c, req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var coder StatusCoder
+			if errors.As(err, &coder) {
+				status = coder.StatusCode()
+			}
+			r.HandleError(c, err, status)
+			return
+		}
+		r.Render(c, http.StatusOK, resp)
+	}, options...)
+
+	if r.Response == nil {
+		r.Response = make(Response)
+	}
+	if _, ok := r.Response["200"]; !ok {
+		var resp R
+		model := any(resp)
+		if _, ok := GetPolymorphic(reflect.TypeOf((*R)(nil)).Elem()); ok {
+			// a nil interface has no type, keep it behind a pointer
+			model = new(R)
+		} else if type_ := reflect.TypeOf(model); type_ != nil && type_.Kind() == reflect.Ptr {
+			model = reflect.New(type_.Elem()).Elem().Interface()
+		}
+		r.Response["200"] = ResponseItem{
+			Description: "Successful Response",
+			Model:       model,
+		}
+	}
+	return r
+}
+
 func (router *Router) WithSecurity(securities ...security.ISecurity) *Router {
 	Security(securities...)(router)
 	return router
@@ -223,3 +421,33 @@ func (router *Router) WithContentType(contentType string, contentTypeType Conten
 	ContentType(contentType, contentTypeType)(router)
 	return router
 }
+
+func (router *Router) WithModelPool() *Router {
+	ModelPool()(router)
+	return router
+}
+
+func (router *Router) WithRequestBody(enabled bool) *Router {
+	RequestBody(enabled)(router)
+	return router
+}
+
+func (router *Router) WithRequestContentTypes(contentTypes ...string) *Router {
+	RequestContentTypes(contentTypes...)(router)
+	return router
+}
+
+func (router *Router) WithResponseContentTypes(contentTypes ...string) *Router {
+	ResponseContentTypes(contentTypes...)(router)
+	return router
+}
+
+func (router *Router) WithReadOnly(mode ReadOnlyMode) *Router {
+	ReadOnly(mode)(router)
+	return router
+}
+
+func (router *Router) WithScopes(scopes ...string) *Router {
+	Scopes(scopes...)(router)
+	return router
+}
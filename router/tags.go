@@ -0,0 +1,39 @@
+package router
+
+import (
+	"reflect"
+	"strings"
+)
+
+// IsParameter report whether the field is bound from the path, query, headers or cookies
+func IsParameter(field reflect.StructField) bool {
+	for _, key := range []string{"uri", "query", "header", "cookie"} {
+		if _, ok := field.Tag.Lookup(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAPIOptions return the options of the openapi tag of a field, e.g. openapi:"readonly,deprecated"
+func OpenAPIOptions(field reflect.StructField) []string {
+	tag, ok := field.Tag.Lookup("openapi")
+	if !ok {
+		return nil
+	}
+	options := strings.Split(tag, ",")
+	for i, option := range options {
+		options[i] = strings.TrimSpace(option)
+	}
+	return options
+}
+
+// HasOpenAPIOption report whether the openapi tag of a field holds option
+func HasOpenAPIOption(field reflect.StructField, option string) bool {
+	for _, o := range OpenAPIOptions(field) {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
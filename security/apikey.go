@@ -1,6 +1,7 @@
 package security
 
 import (
+	"context"
 	"errors"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -10,16 +11,38 @@ import (
 type ApiKey struct {
 	Security
 	Name string
-	In   string
+	// In is where the key is sent, header, query or cookie, header when empty
+	In string
+	// Store, when set, validate the key and pass its principal to Callback instead of the raw key
+	Store KeyStore
 }
 
 func (k *ApiKey) Authorize(c *gin.Context) {
-	auth := c.Request.Header.Get(k.Name)
+	var auth string
+	switch k.in() {
+	case "query":
+		auth = c.Query(k.Name)
+	case "cookie":
+		auth, _ = c.Cookie(k.Name)
+	default:
+		auth = c.Request.Header.Get(k.Name)
+	}
 	if auth == "" {
 		k.Callback(c, nil, errors.New("empty apikey"))
-	} else {
+	} else if k.Store == nil {
 		k.Callback(c, auth, nil)
+	} else if principal, err := k.Store.Lookup(c.Request.Context(), auth); err != nil {
+		k.Callback(c, nil, err)
+	} else {
+		k.Callback(c, principal, nil)
+	}
+}
+
+func (k *ApiKey) in() string {
+	if k.In == "" {
+		return "header"
 	}
+	return k.In
 }
 
 func (k *ApiKey) Provider() string {
@@ -29,7 +52,12 @@ func (k *ApiKey) Provider() string {
 func (k *ApiKey) Scheme() *openapi3.SecurityScheme {
 	return &openapi3.SecurityScheme{
 		Type: "apiKey",
-		In:   k.In,
+		In:   k.in(),
 		Name: k.Name,
 	}
 }
+
+// KeyStore validate api keys and return the principal they authenticate
+type KeyStore interface {
+	Lookup(ctx context.Context, key string) (interface{}, error)
+}
@@ -10,22 +10,53 @@ import (
 
 type Bearer struct {
 	Security
+	// Verifier, when set, verify the token and pass its *Claims to Callback instead of the raw token
+	Verifier TokenVerifier
 }
 
 func (b *Bearer) Authorize(c *gin.Context) {
+	token, err := bearerToken(c)
+	if err != nil {
+		b.Callback(c, nil, err)
+	} else if b.Verifier == nil {
+		b.Callback(c, token, nil)
+	} else {
+		verifyToken(c, b.Verifier, token, b.Callback)
+	}
+}
+
+// bearerToken return the token of the Authorization header
+func bearerToken(c *gin.Context) (string, error) {
 	auth := c.Request.Header.Get("Authorization")
 	if auth == "" {
-		b.Callback(c, nil, errors.New("empty authentication"))
+		return "", errors.New("empty authentication")
+	}
+	splits := strings.Split(auth, "Bearer ")
+	if len(splits) != 2 {
+		return "", errors.New("invalid authentication string")
+	}
+	return splits[1], nil
+}
+
+// verifyToken pass the claims of token, or the error verifying it or checking the scopes
+// required by the route, to callback
+func verifyToken(c *gin.Context, verifier TokenVerifier, token string, callback func(*gin.Context, interface{}, error)) {
+	claims, err := verifier.Verify(c.Request.Context(), token)
+	if err == nil {
+		err = checkScopes(c, claims)
+	}
+	if err != nil {
+		callback(c, nil, err)
 	} else {
-		splits := strings.Split(auth, "Bearer ")
-		if len(splits) != 2 {
-			b.Callback(c, nil, errors.New("invalid authentication string"))
-		} else {
-			b.Callback(c, splits[1], nil)
-		}
+		callback(c, claims, nil)
 	}
 }
 
+// ChecksScopes is true when the tokens are verified
+func (b *Bearer) ChecksScopes() bool {
+	return b.Verifier != nil
+}
+
 func (b *Bearer) Provider() string {
 	return BearerAuth
 }
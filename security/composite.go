@@ -0,0 +1,137 @@
+package security
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// ProviderCredentials is the gin.Context key holding the credentials of every scheme that
+// authorized the request through a Composite, by Provider
+const ProviderCredentials = "provider_credentials"
+
+// tryKey is the gin.Context key holding where Callback records the error of a scheme tried by a Composite
+const tryKey = "swagger_gin_security_try"
+
+// Composite authorize requests when any, or all, of its securities do. The securities report
+// their failure through Security.Callback, which a Composite collects instead of responding
+type Composite struct {
+	Security
+	All        bool
+	Securities []ISecurity
+}
+
+// AnyOf authorize requests authorized by one of the securities, tried in order
+func AnyOf(securities ...ISecurity) *Composite {
+	return &Composite{Securities: securities}
+}
+
+// AllOf authorize requests authorized by every one of the securities
+func AllOf(securities ...ISecurity) *Composite {
+	return &Composite{All: true, Securities: securities}
+}
+
+func (s *Composite) Authorize(c *gin.Context) {
+	var errs []error
+	var first interface{}
+	for i, security := range s.Securities {
+		if err := try(c, security); err != nil {
+			errs = append(errs, err)
+			if s.All {
+				break
+			}
+			continue
+		}
+		credentials := c.Value(Credentials)
+		byProvider, _ := c.Value(ProviderCredentials).(map[string]interface{})
+		if byProvider == nil {
+			byProvider = make(map[string]interface{})
+			c.Set(ProviderCredentials, byProvider)
+		}
+		byProvider[security.Provider()] = credentials
+		if !s.All {
+			s.Callback(c, credentials, nil)
+			return
+		}
+		if i == 0 {
+			first = credentials
+		}
+	}
+	if len(errs) > 0 || len(s.Securities) == 0 {
+		s.Callback(c, nil, compositeError(errs))
+		return
+	}
+	// AllOf keep the credentials of the first scheme, the others are in ProviderCredentials
+	s.Callback(c, first, nil)
+}
+
+// try run the Authorize of security without responding on failure, and return the error it reported
+func try(c *gin.Context, security ISecurity) error {
+	var err error
+	previous, _ := c.Value(tryKey).(*error)
+	c.Set(tryKey, &err)
+	security.Authorize(c)
+	c.Set(tryKey, previous)
+	return err
+}
+
+func (s *Composite) Provider() string {
+	providers := make([]string, 0, len(s.Securities))
+	for _, security := range s.Securities {
+		providers = append(providers, security.Provider())
+	}
+	if s.All {
+		return "AllOf(" + strings.Join(providers, ",") + ")"
+	}
+	return "AnyOf(" + strings.Join(providers, ",") + ")"
+}
+
+// Scheme is nil, the schemes of a Composite are documented through Requirements
+func (s *Composite) Scheme() *openapi3.SecurityScheme {
+	return nil
+}
+
+// Requirements expand security into the alternative sets of schemes that authorize a request,
+// the OpenAPI security requirements of security
+func Requirements(security ISecurity) [][]ISecurity {
+	composite, ok := security.(*Composite)
+	if !ok {
+		return [][]ISecurity{{security}}
+	}
+	if !composite.All {
+		var requirements [][]ISecurity
+		for _, s := range composite.Securities {
+			requirements = append(requirements, Requirements(s)...)
+		}
+		return requirements
+	}
+	requirements := [][]ISecurity{{}}
+	for _, s := range composite.Securities {
+		var product [][]ISecurity
+		for _, requirement := range requirements {
+			for _, alternative := range Requirements(s) {
+				product = append(product, append(append([]ISecurity{}, requirement...), alternative...))
+			}
+		}
+		requirements = product
+	}
+	return requirements
+}
+
+type compositeError []error
+
+func (e compositeError) Error() string {
+	if len(e) == 0 {
+		return "no security scheme"
+	}
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e compositeError) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,67 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrTokenInactive = errors.New("token is not active")
+
+// Introspection is a TokenVerifier asking an RFC 7662 introspection endpoint whether tokens are active
+type Introspection struct {
+	URL string
+	// ClientID and ClientSecret authenticate the requests with HTTP Basic when set
+	ClientID     string
+	ClientSecret string
+	Client       *http.Client
+}
+
+func (i *Introspection) Verify(ctx context.Context, token string) (*Claims, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if i.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(i.ClientID), url.QueryEscape(i.ClientSecret))
+	}
+	client := i.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspecting token: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Active bool `json:"active"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if !result.Active {
+		return nil, ErrTokenInactive
+	}
+	claims := &Claims{raw: body}
+	if err := json.Unmarshal(body, claims); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,236 @@
+package security
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKS is a KeySet loaded from a JSON Web Key Set document, it is reloaded every RefreshInterval
+// and when a token names an unknown kid, so that rotated keys are picked up
+type JWKS struct {
+	// URL of the document, http(s) URLs are fetched and file:// URLs or paths are read from disk,
+	// symmetric keys are only accepted from disk
+	URL    string
+	Client *http.Client
+	// RefreshInterval after which the document is reloaded, one hour when zero
+	RefreshInterval time.Duration
+	// MinRefreshInterval between reloads caused by an unknown kid or a failed load, 30 seconds when zero
+	MinRefreshInterval time.Duration
+
+	mu       sync.Mutex
+	keys     []jsonWebKey
+	loadedAt time.Time
+	reloader reloader
+}
+
+func (j *JWKS) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	refreshInterval := j.RefreshInterval
+	if refreshInterval == 0 {
+		refreshInterval = time.Hour
+	}
+	minRefreshInterval := j.MinRefreshInterval
+	if minRefreshInterval == 0 {
+		minRefreshInterval = 30 * time.Second
+	}
+
+	j.mu.Lock()
+	stale := j.keys == nil || time.Since(j.loadedAt) >= refreshInterval
+	j.mu.Unlock()
+	if stale {
+		// keep serving the previous keys when the document can't be reloaded
+		if err := j.refresh(ctx, minRefreshInterval); err != nil && j.currentKeys() == nil {
+			return nil, err
+		}
+	}
+	key, ok := lookupKey(j.currentKeys(), kid, alg)
+	if !ok {
+		if err := j.refresh(ctx, minRefreshInterval); err != nil {
+			return nil, err
+		}
+		key, ok = lookupKey(j.currentKeys(), kid, alg)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownSigningKey, kid)
+	}
+	return key.key, nil
+}
+
+func (j *JWKS) currentKeys() []jsonWebKey {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.keys
+}
+
+// refresh reload the document unless the last attempt is less than minRefreshInterval old
+func (j *JWKS) refresh(ctx context.Context, minRefreshInterval time.Duration) error {
+	return j.reloader.reload(ctx, minRefreshInterval, func(ctx context.Context) error {
+		keys, err := j.load(ctx)
+		if err != nil {
+			return err
+		}
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.keys = keys
+		j.loadedAt = time.Now()
+		return nil
+	})
+}
+
+// lookupKey find the key of kid usable with alg, tokens without kid use the only key of the set
+func lookupKey(keys []jsonWebKey, kid, alg string) (jsonWebKey, bool) {
+	var found []jsonWebKey
+	for _, key := range keys {
+		if (kid == "" || key.Kid == kid) && (key.Alg == "" || key.Alg == alg) {
+			found = append(found, key)
+		}
+	}
+	if len(found) != 1 {
+		return jsonWebKey{}, false
+	}
+	return found[0], true
+}
+
+func (j *JWKS) load(ctx context.Context) ([]jsonWebKey, error) {
+	data, err := j.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid JWKS document: %w", err)
+	}
+	keys := make([]jsonWebKey, 0, len(document.Keys))
+	for _, key := range document.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		// anyone able to fetch a remote document could sign tokens with its symmetric keys
+		if key.Kty == "oct" && isRemote(j.URL) {
+			continue
+		}
+		// skip the key types and curves this package can't verify with
+		if key.key, err = key.publicKey(); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	return readDocument(ctx, j.Client, j.URL)
+}
+
+// readDocument fetch an http(s) URL, or read a file:// URL or a path from disk
+func readDocument(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	if !isRemote(url) {
+		return os.ReadFile(strings.TrimPrefix(url, "file://"))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func isRemote(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+	key interface{}
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,333 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrTokenMalformed    = errors.New("malformed token")
+	ErrTokenAlgorithm    = errors.New("token algorithm not allowed")
+	ErrTokenSignature    = errors.New("invalid token signature")
+	ErrTokenExpired      = errors.New("token is expired")
+	ErrTokenNotValidYet  = errors.New("token is not valid yet")
+	ErrTokenIssuer       = errors.New("invalid token issuer")
+	ErrTokenAudience     = errors.New("invalid token audience")
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+)
+
+// Algorithms supported by JWTVerifier
+var Algorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// KeySet return the key verifying tokens signed with kid and alg, []byte for HMAC,
+// *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+type KeySet interface {
+	Key(ctx context.Context, kid, alg string) (interface{}, error)
+}
+
+// StaticKeys is a KeySet of fixed keys by kid, tokens without kid use the only key of the set
+type StaticKeys map[string]interface{}
+
+func (k StaticKeys) Key(_ context.Context, kid, _ string) (interface{}, error) {
+	if key, ok := k[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(k) == 1 {
+		for _, key := range k {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrUnknownSigningKey, kid)
+}
+
+// TokenVerifier verify a bearer token and return its claims
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// JWTVerifier verify the signature and the registered claims of JSON Web Tokens
+type JWTVerifier struct {
+	Keys KeySet
+	// Issuer is the expected iss claim, not checked when empty
+	Issuer string
+	// Audience is an expected aud claim, not checked when empty
+	Audience string
+	// Algorithms allowed to sign tokens, all the supported ones when empty
+	Algorithms []string
+	// ClockSkew tolerated when checking exp and nbf
+	ClockSkew time.Duration
+	// Now return the current time, time.Now when nil
+	Now func() time.Time
+}
+
+// Verify check the signature of token, its exp, nbf, iss and aud claims, and return its claims
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+	}
+
+	if !v.allows(header.Alg) {
+		return nil, fmt.Errorf("%w: '%s'", ErrTokenAlgorithm, header.Alg)
+	}
+	key, err := v.Keys.Key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{raw: payload}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) allows(alg string) bool {
+	algorithms := v.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = Algorithms
+	}
+	for _, algorithm := range algorithms {
+		if algorithm == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *JWTVerifier) validate(claims *Claims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if claims.ExpiresAt != 0 && !now.Before(claims.ExpiresAt.Time().Add(v.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.ClockSkew).Before(claims.NotBefore.Time()) {
+		return ErrTokenNotValidYet
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return ErrTokenIssuer
+	}
+	if v.Audience != "" && !claims.Audience.Contains(v.Audience) {
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrTokenMalformed, err)
+	}
+	return nil
+}
+
+// verifySignature check signature with key, the key type must match the algorithm family
+func verifySignature(alg string, key interface{}, signingInput, signature []byte) error {
+	if alg == "EdDSA" {
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(publicKey, signingInput, signature) {
+			return ErrTokenSignature
+		}
+		return nil
+	}
+
+	if len(alg) < 3 {
+		return fmt.Errorf("%w: '%s'", ErrTokenAlgorithm, alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: '%s'", ErrTokenAlgorithm, alg)
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrTokenSignature
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signingInput)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return ErrTokenSignature
+		}
+		return nil
+	}
+
+	digest := hash.New()
+	digest.Write(signingInput)
+	hashed := digest.Sum(nil)
+	switch alg[:2] {
+	case "RS", "PS":
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrTokenSignature
+		}
+		var err error
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(publicKey, hash, hashed, signature)
+		} else {
+			err = rsa.VerifyPSS(publicKey, hash, hashed, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrTokenSignature
+		}
+	case "ES":
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrTokenSignature
+		}
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if publicKey.Curve.Params().BitSize != map[crypto.Hash]int{crypto.SHA256: 256, crypto.SHA384: 384, crypto.SHA512: 521}[hash] ||
+			len(signature) != 2*size {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(publicKey, hashed, r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return fmt.Errorf("%w: '%s'", ErrTokenAlgorithm, alg)
+	}
+	return nil
+}
+
+// NumericDate is a JWT date, the number of seconds since the epoch
+type NumericDate int64
+
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	*d = NumericDate(seconds)
+	return nil
+}
+
+func (d NumericDate) Time() time.Time {
+	return time.Unix(int64(d), 0)
+}
+
+// Audience is the aud claim, a single string or an array of strings
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeList is a list of scopes encoded as an array or a space separated string
+type ScopeList []string
+
+func (s *ScopeList) UnmarshalJSON(data []byte) error {
+	var joined string
+	if err := json.Unmarshal(data, &joined); err == nil {
+		*s = strings.Fields(joined)
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+// Claims are the registered claims of a verified token, Decode reads the others
+type Claims struct {
+	Issuer    string      `json:"iss,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  Audience    `json:"aud,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	ID        string      `json:"jti,omitempty"`
+	Scope     string      `json:"scope,omitempty"`
+	// Scp is the scp claim some issuers use instead of scope
+	Scp ScopeList `json:"scp,omitempty"`
+	raw []byte
+}
+
+// Decode unmarshal all the claims of the token into v, e.g. a struct of custom claims
+func (c *Claims) Decode(v interface{}) error {
+	return json.Unmarshal(c.raw, v)
+}
+
+// GetClaims return the claims of the token verified for the request
+func GetClaims(c *gin.Context) (*Claims, bool) {
+	claims, ok := c.Value(Credentials).(*Claims)
+	return claims, ok
+}
+
+// DecodeClaims decode the claims of the token verified for the request into T
+func DecodeClaims[T any](c *gin.Context) (T, error) {
+	var v T
+	claims, ok := GetClaims(c)
+	if !ok {
+		return v, errors.New("no verified token claims")
+	}
+	err := claims.Decode(&v)
+	return v, err
+}
@@ -0,0 +1,89 @@
+package security
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidApiKey = errors.New("invalid apikey")
+	ErrApiKeyExpired = errors.New("apikey is expired")
+)
+
+// HashKey return the hex SHA-256 of key, the form keys are kept in by HashedKeyStore
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type storedKey struct {
+	principal interface{}
+	expiresAt time.Time
+}
+
+// HashedKeyStore is an in-memory KeyStore of key hashes, so that the keys themselves are never kept
+type HashedKeyStore struct {
+	// Now return the current time, time.Now when nil
+	Now func() time.Time
+
+	mu   sync.RWMutex
+	keys map[string]storedKey
+}
+
+// Add the key of hash for principal, a zero expiresAt never expires
+func (s *HashedKeyStore) Add(hash string, principal interface{}, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[string]storedKey)
+	}
+	s.keys[hash] = storedKey{principal: principal, expiresAt: expiresAt}
+}
+
+// Revoke the key of hash
+func (s *HashedKeyStore) Revoke(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, hash)
+}
+
+// Rotate replace the key of oldHash by the key of newHash, expiring at expiresAt, for the same
+// principal, the old key stays valid for grace so that clients can switch over
+func (s *HashedKeyStore) Rotate(oldHash, newHash string, expiresAt time.Time, grace time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.keys[oldHash]
+	if !ok {
+		return ErrInvalidApiKey
+	}
+	s.keys[newHash] = storedKey{principal: old.principal, expiresAt: expiresAt}
+	if deadline := s.now().Add(grace); old.expiresAt.IsZero() || deadline.Before(old.expiresAt) {
+		old.expiresAt = deadline
+	}
+	s.keys[oldHash] = old
+	return nil
+}
+
+func (s *HashedKeyStore) Lookup(_ context.Context, key string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stored, ok := s.keys[HashKey(key)]
+	if !ok {
+		return nil, ErrInvalidApiKey
+	}
+	if !stored.expiresAt.IsZero() && !s.now().Before(stored.expiresAt) {
+		return nil, ErrApiKeyExpired
+	}
+	return stored.principal, nil
+}
+
+func (s *HashedKeyStore) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
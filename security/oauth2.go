@@ -1,6 +1,8 @@
 package security
 
 import (
+	"errors"
+
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +13,28 @@ type OAuth2 struct {
 	TokenURL         string
 	RefreshURL       string
 	Scopes           map[string]string
+	// Verifier validate the access tokens, e.g. a JWTVerifier on the issuer's JWKS or an Introspection
+	Verifier TokenVerifier
 }
 
+var ErrNoTokenVerifier = errors.New("no token verifier configured")
+
 func (i *OAuth2) Authorize(c *gin.Context) {
+	token, err := bearerToken(c)
+	if err == nil && i.Verifier == nil {
+		// without a verifier every token would be accepted
+		err = ErrNoTokenVerifier
+	}
+	if err != nil {
+		i.Callback(c, nil, err)
+	} else {
+		verifyToken(c, i.Verifier, token, i.Callback)
+	}
+}
 
+// ChecksScopes is always true, tokens are rejected when there is no Verifier
+func (i *OAuth2) ChecksScopes() bool {
+	return true
 }
 
 func (i *OAuth2) Provider() string {
@@ -1,6 +1,14 @@
 package security
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 )
@@ -8,10 +16,99 @@ import (
 type OpenID struct {
 	Security
 	ConnectUrl string
+	// Audience is the expected aud claim of the tokens verified against the discovered JWKS, it is
+	// required unless AnyAudience is set
+	Audience string
+	// AnyAudience explicitly accept tokens the issuer issued to any client when Audience is empty
+	AnyAudience bool
+	Client      *http.Client
+	// Verifier replace the JWKS verifier built from the discovery document, e.g. an Introspection
+	Verifier TokenVerifier
+	// RetryInterval between attempts to load the discovery document after a failure, 30 seconds when zero
+	RetryInterval time.Duration
+
+	mu         sync.Mutex
+	discovered TokenVerifier
+	reloader   reloader
 }
 
 func (i *OpenID) Authorize(c *gin.Context) {
+	token, err := bearerToken(c)
+	var verifier TokenVerifier
+	if err == nil {
+		verifier, err = i.verifier(c.Request.Context())
+	}
+	if err != nil {
+		i.Callback(c, nil, err)
+	} else {
+		verifyToken(c, verifier, token, i.Callback)
+	}
+}
+
+// verifier return the Verifier, or a JWTVerifier on the issuer and jwks_uri of the discovery
+// document loaded from ConnectUrl on first use
+func (i *OpenID) verifier(ctx context.Context) (TokenVerifier, error) {
+	if i.Verifier != nil {
+		return i.Verifier, nil
+	}
+	if discovered := i.discoveredVerifier(); discovered != nil {
+		return discovered, nil
+	}
+	if i.Audience == "" && !i.AnyAudience {
+		return nil, errors.New("OpenID Audience is not configured")
+	}
+	retryInterval := i.RetryInterval
+	if retryInterval == 0 {
+		retryInterval = 30 * time.Second
+	}
+	if err := i.reloader.reload(ctx, retryInterval, i.discover); err != nil {
+		return nil, err
+	}
+	if discovered := i.discoveredVerifier(); discovered != nil {
+		return discovered, nil
+	}
+	return nil, errors.New("OpenID discovery document not loaded")
+}
+
+func (i *OpenID) discoveredVerifier() TokenVerifier {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.discovered
+}
+
+// discover load the discovery document and build the verifier on its issuer and jwks_uri
+func (i *OpenID) discover(ctx context.Context) error {
+	data, err := readDocument(ctx, i.Client, i.ConnectUrl)
+	if err != nil {
+		return err
+	}
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := json.Unmarshal(data, &discovery); err != nil {
+		return fmt.Errorf("invalid OpenID discovery document: %w", err)
+	}
+	if discovery.Issuer == "" || discovery.JWKSURI == "" {
+		return errors.New("invalid OpenID discovery document: missing issuer or jwks_uri")
+	}
+	// only a JWKS configured directly may be read from disk
+	if !isRemote(discovery.JWKSURI) {
+		return errors.New("invalid OpenID discovery document: jwks_uri is not an http(s) URL")
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.discovered = &JWTVerifier{
+		Keys:     &JWKS{URL: discovery.JWKSURI, Client: i.Client},
+		Issuer:   discovery.Issuer,
+		Audience: i.Audience,
+	}
+	return nil
+}
 
+// ChecksScopes is always true, the tokens are verified by the Verifier or on the discovered JWKS
+func (i *OpenID) ChecksScopes() bool {
+	return true
 }
 
 func (i *OpenID) Provider() string {
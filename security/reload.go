@@ -0,0 +1,53 @@
+package security
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// reloader run the loads of a remote document one at a time, concurrent callers share the load
+// in progress, which runs without holding the mutex, and a load attempted less than an interval
+// ago isn't retried, its error is returned again
+type reloader struct {
+	mu          sync.Mutex
+	attemptedAt time.Time
+	err         error
+	// loading is closed once the load in progress is done
+	loading chan struct{}
+}
+
+func (r *reloader) reload(ctx context.Context, minInterval time.Duration, load func(ctx context.Context) error) error {
+	r.mu.Lock()
+	if loading := r.loading; loading != nil {
+		r.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.err
+	}
+	if !r.attemptedAt.IsZero() && time.Since(r.attemptedAt) < minInterval {
+		defer r.mu.Unlock()
+		return r.err
+	}
+	loading := make(chan struct{})
+	r.loading = loading
+	r.mu.Unlock()
+
+	err := load(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// a canceled request doesn't hold back the next load
+	if err == nil || ctx.Err() == nil {
+		r.attemptedAt = time.Now()
+	}
+	r.err = err
+	r.loading = nil
+	close(loading)
+	return err
+}
@@ -0,0 +1,69 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopesKey is the gin.Context key holding the scopes required by the route
+const ScopesKey = "swagger_gin_scopes"
+
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// ScopeChecker is implemented by securities that check the scopes required by the route
+type ScopeChecker interface {
+	ChecksScopes() bool
+}
+
+// ChecksScopes report whether every alternative of security has a scheme checking the scopes
+// required by the route, otherwise a request could be authorized without them
+func ChecksScopes(security ISecurity) bool {
+	for _, requirement := range Requirements(security) {
+		checked := false
+		for _, s := range requirement {
+			if checker, ok := s.(ScopeChecker); ok && checker.ChecksScopes() {
+				checked = true
+				break
+			}
+		}
+		if !checked {
+			return false
+		}
+	}
+	return true
+}
+
+// Scopes return the space separated scopes of the scope claim along with those of the scp claim
+func (c *Claims) Scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+// HasScopes report whether the token was granted all the scopes
+func (c *Claims) HasScopes(scopes ...string) bool {
+	return len(missingScopes(c, scopes)) == 0
+}
+
+// checkScopes reject claims lacking a scope required by the route with ErrInsufficientScope
+func checkScopes(c *gin.Context, claims *Claims) error {
+	if missing := missingScopes(claims, c.GetStringSlice(ScopesKey)); len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInsufficientScope, strings.Join(missing, " "))
+	}
+	return nil
+}
+
+func missingScopes(claims *Claims, required []string) []string {
+	granted := make(map[string]bool)
+	for _, scope := range claims.Scopes() {
+		granted[scope] = true
+	}
+	var missing []string
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
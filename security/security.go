@@ -1,10 +1,14 @@
 package security
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
+	"github.com/sparkle-technologies/swagger_gin/problem"
 )
 
 const (
@@ -16,6 +20,9 @@ const (
 	OAuth2Auth  = "OAuth2Auth"
 )
 
+// ISecurity authorize the requests of a route and document its scheme. Schemes combined with
+// AnyOf or AllOf must report failures through Callback, usually the one of an embedded
+// Security, rather than write the response themselves, or the Composite can't try the next one
 type ISecurity interface {
 	Authorize(g *gin.Context)
 	Callback(c *gin.Context, credentials interface{}, err error)
@@ -28,8 +35,17 @@ type Security struct {
 }
 
 func (s *Security) Callback(c *gin.Context, credentials interface{}, err error) {
-	if err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
+	if tried, _ := c.Value(tryKey).(*error); tried != nil && err != nil {
+		// a Composite is trying this scheme, it responds once all its schemes are tried
+		*tried = err
+		return
+	}
+	if errors.Is(err, ErrInsufficientScope) {
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`,
+			strings.Join(c.GetStringSlice(ScopesKey), " ")))
+		problem.Write(c, problem.New(http.StatusForbidden, err.Error()))
+	} else if err != nil {
+		problem.Write(c, problem.New(http.StatusUnauthorized, err.Error()))
 	} else {
 		c.Set(Credentials, credentials)
 	}
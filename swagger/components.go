@@ -0,0 +1,261 @@
+package swagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// requestSuffix mark the provisional names of request components until nameComponents settles them
+const requestSuffix = "~request"
+
+var refRegex = regexp.MustCompile(`"#/components/schemas/([^"]*)"`)
+
+// componentRef build the request or response component of type_ and return its ref
+func (swagger *Swagger) componentRef(type_ reflect.Type, isRequest bool) string {
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	swagger.getComponentByModel(reflect.New(type_).Interface(), isRequest)
+	return generateRefName(swagger.schemaTypes[schemaKey{type_: type_, request: isRequest}])
+}
+
+// nameComponents give the components their final names once every route is built, so that names
+// don't depend on the order routes are registered in. A request component keeps the name of its
+// type when the type has no response component or both schemas are the same, and is suffixed
+// with Request otherwise, every ref of the document is rewritten accordingly
+func (swagger *Swagger) nameComponents() {
+	schemas := swagger.OpenAPI.Components.Schemas
+	names := swagger.typeComponentNames()
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[name] = true
+	}
+
+	var requests []schemaKey
+	for key := range swagger.schemaTypes {
+		if key.request {
+			requests = append(requests, key)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return swagger.schemaTypes[requests[i]] < swagger.schemaTypes[requests[j]]
+	})
+
+	// start with every request component merged into its response component, and split the ones
+	// whose schemas differ until no more do, so that recursive types merge as well
+	var candidates []schemaKey
+	for _, key := range requests {
+		names[swagger.schemaTypes[key]] = names[swagger.schemaName(key.type_)]
+		if _, ok := swagger.schemaTypes[schemaKey{type_: key.type_}]; ok {
+			candidates = append(candidates, key)
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, key := range candidates {
+			request := swagger.schemaTypes[key]
+			response := swagger.schemaTypes[schemaKey{type_: key.type_}]
+			if names[request] != names[response] {
+				continue
+			}
+			if canonicalSchema(schemas[request], names) != canonicalSchema(schemas[response], names) {
+				name := names[response] + "Request"
+				for i := 2; taken[name]; i++ {
+					name = fmt.Sprintf("%sRequest%d", names[response], i)
+				}
+				taken[name] = true
+				names[request] = name
+				changed = true
+			}
+		}
+	}
+
+	final := func(name string) string {
+		if renamed, ok := names[name]; ok {
+			return renamed
+		}
+		return name
+	}
+	renamed := make(openapi3.Schemas, len(schemas))
+	rename := func(name string) {
+		schemaRef := schemas[name]
+		to := final(name)
+		if _, ok := renamed[to]; ok {
+			// a request component merged into its response component
+			return
+		}
+		if schemaRef.Value != nil && schemaRef.Value.Title == name {
+			schemaRef.Value.Title = to
+		}
+		renamed[to] = schemaRef
+	}
+	for _, name := range sortedKeys(schemas) {
+		if !strings.HasSuffix(name, requestSuffix) {
+			rename(name)
+		}
+	}
+	for _, name := range sortedKeys(schemas) {
+		if strings.HasSuffix(name, requestSuffix) {
+			rename(name)
+		}
+	}
+	swagger.OpenAPI.Components.Schemas = renamed
+	for key, name := range swagger.schemaTypes {
+		swagger.schemaTypes[key] = final(name)
+	}
+
+	walkSchemaRefs(swagger.OpenAPI, func(schemaRef *openapi3.SchemaRef) {
+		if name, ok := strings.CutPrefix(schemaRef.Ref, generateRefName("")); ok {
+			schemaRef.Ref = generateRefName(final(name))
+		}
+	}, func(schema *openapi3.Schema) {
+		if schema.Discriminator == nil {
+			return
+		}
+		for value, ref := range schema.Discriminator.Mapping {
+			if name, ok := strings.CutPrefix(ref, generateRefName("")); ok {
+				schema.Discriminator.Mapping[value] = generateRefName(final(name))
+			}
+		}
+	})
+}
+
+// typeComponentNames map the provisional component name of every type to its final name. All
+// the types whose names collide are qualified with their package name, so that a route doesn't
+// rename the components of the others
+func (swagger *Swagger) typeComponentNames() map[string]string {
+	naming := swagger.SchemaNaming
+	if naming == nil {
+		naming = ShortName
+	}
+	types := make([]reflect.Type, 0, len(swagger.typeNames))
+	counts := make(map[string]int, len(swagger.typeNames))
+	for type_ := range swagger.typeNames {
+		types = append(types, type_)
+		counts[naming(type_)]++
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].PkgPath()+"."+types[i].Name() < types[j].PkgPath()+"."+types[j].Name()
+	})
+
+	names := make(map[string]string, len(types))
+	taken := make(map[string]bool, len(types))
+	var colliding []reflect.Type
+	for _, type_ := range types {
+		if name := naming(type_); counts[name] == 1 {
+			names[swagger.typeNames[type_]] = name
+			taken[name] = true
+		} else {
+			colliding = append(colliding, type_)
+		}
+	}
+	for _, type_ := range colliding {
+		name := QualifiedName(type_)
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s%d", QualifiedName(type_), i)
+		}
+		taken[name] = true
+		names[swagger.typeNames[type_]] = name
+	}
+	return names
+}
+
+// canonicalSchema render a component without its title and with its refs renamed, for comparison
+func canonicalSchema(schemaRef *openapi3.SchemaRef, names map[string]string) string {
+	if schemaRef == nil || schemaRef.Value == nil {
+		return ""
+	}
+	schema := *schemaRef.Value
+	schema.Title = ""
+	data, err := json.Marshal(&schema)
+	if err != nil {
+		return ""
+	}
+	return refRegex.ReplaceAllStringFunc(string(data), func(ref string) string {
+		name := refRegex.FindStringSubmatch(ref)[1]
+		if renamed, ok := names[name]; ok {
+			name = renamed
+		}
+		return `"` + generateRefName(name) + `"`
+	})
+}
+
+// walkSchemaRefs call refFn on every schema ref, and schemaFn on every schema, of the components
+// and operations of doc once
+func walkSchemaRefs(doc *openapi3.T, refFn func(*openapi3.SchemaRef), schemaFn func(*openapi3.Schema)) {
+	seenRefs := make(map[*openapi3.SchemaRef]bool)
+	seenSchemas := make(map[*openapi3.Schema]bool)
+	var walk func(schemaRef *openapi3.SchemaRef)
+	walk = func(schemaRef *openapi3.SchemaRef) {
+		if schemaRef == nil || seenRefs[schemaRef] {
+			return
+		}
+		seenRefs[schemaRef] = true
+		refFn(schemaRef)
+		schema := schemaRef.Value
+		if schema == nil || seenSchemas[schema] {
+			return
+		}
+		seenSchemas[schema] = true
+		schemaFn(schema)
+		for _, property := range schema.Properties {
+			walk(property)
+		}
+		walk(schema.Items)
+		walk(schema.AdditionalProperties.Schema)
+		walk(schema.Not)
+		for _, schemaRefs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+			for _, ref := range schemaRefs {
+				walk(ref)
+			}
+		}
+	}
+	walkContent := func(content openapi3.Content) {
+		for _, mediaType := range content {
+			if mediaType != nil {
+				walk(mediaType.Schema)
+			}
+		}
+	}
+
+	for _, schemaRef := range doc.Components.Schemas {
+		walk(schemaRef)
+	}
+	if doc.Paths == nil {
+		return
+	}
+	for _, pathItem := range doc.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			for _, parameter := range operation.Parameters {
+				if parameter.Value != nil {
+					walk(parameter.Value.Schema)
+					walkContent(parameter.Value.Content)
+				}
+			}
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				walkContent(operation.RequestBody.Value.Content)
+			}
+			if operation.Responses == nil {
+				continue
+			}
+			for _, response := range operation.Responses.Map() {
+				if response.Value == nil {
+					continue
+				}
+				walkContent(response.Value.Content)
+				for _, header := range response.Value.Headers {
+					if header.Value != nil {
+						walk(header.Value.Schema)
+						walkContent(header.Value.Content)
+					}
+				}
+			}
+		}
+	}
+}
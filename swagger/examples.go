@@ -0,0 +1,128 @@
+package swagger
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/structtag"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ExampleAble models provide named examples of the request or response bodies they describe,
+// Examples is called on the zero value of the model
+type ExampleAble interface {
+	Examples() map[string]interface{}
+}
+
+// applyValueTags set the default and example of schema from the default and example tags,
+// converted to the type of schema
+func applyValueTags(schema *openapi3.Schema, tags *structtag.Tags) {
+	if schema == nil {
+		return
+	}
+	if defaultTag, err := tags.Get(DEFAULT); err == nil {
+		schema.Default = tagValue(schema, defaultTag.Value())
+	}
+	if exampleTag, err := tags.Get(EXAMPLE); err == nil {
+		schema.Example = tagValue(schema, exampleTag.Value())
+	} else if examples := namedExamples(tags); len(examples) > 0 {
+		// schemas hold a single example, use the first named one
+		schema.Example = tagValue(schema, examples[0][1])
+	}
+}
+
+// getExamples build the named examples of the examples tag, e.g. examples:"small=1;large=100"
+func getExamples(schema *openapi3.Schema, tags *structtag.Tags) openapi3.Examples {
+	named := namedExamples(tags)
+	if len(named) == 0 {
+		return nil
+	}
+	examples := make(openapi3.Examples, len(named))
+	for _, example := range named {
+		examples[example[0]] = &openapi3.ExampleRef{Value: openapi3.NewExample(tagValue(schema, example[1]))}
+	}
+	return examples
+}
+
+func namedExamples(tags *structtag.Tags) [][2]string {
+	examplesTag, err := tags.Get(EXAMPLES)
+	if err != nil {
+		return nil
+	}
+	var examples [][2]string
+	for _, example := range strings.Split(examplesTag.Value(), ";") {
+		name, value, ok := strings.Cut(example, "=")
+		if !ok {
+			continue
+		}
+		examples = append(examples, [2]string{strings.TrimSpace(name), value})
+	}
+	return examples
+}
+
+// getMediaTypeExamples return the named examples of models implementing ExampleAble
+func getMediaTypeExamples(type_ reflect.Type) openapi3.Examples {
+	if type_ == nil {
+		return nil
+	}
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	exampleAble, ok := reflect.New(type_).Interface().(ExampleAble)
+	if !ok {
+		return nil
+	}
+	examples := make(openapi3.Examples)
+	for name, example := range exampleAble.Examples() {
+		examples[name] = &openapi3.ExampleRef{Value: openapi3.NewExample(example)}
+	}
+	return examples
+}
+
+// tagValue convert the raw value of a tag to the type of schema, e.g. "10" -> 10 for integers,
+// arrays accept comma separated items or JSON and objects accept JSON
+func tagValue(schema *openapi3.Schema, raw string) interface{} {
+	if schema == nil || schema.Type == nil {
+		return jsonValue(raw)
+	}
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger):
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n
+		}
+	case schema.Type.Is(openapi3.TypeNumber):
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+	case schema.Type.Is(openapi3.TypeBoolean):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case schema.Type.Is(openapi3.TypeArray):
+		if strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			return jsonValue(raw)
+		}
+		var items *openapi3.Schema
+		if schema.Items != nil {
+			items = schema.Items.Value
+		}
+		values := make([]interface{}, 0)
+		for _, item := range strings.Split(raw, ",") {
+			values = append(values, tagValue(items, strings.TrimSpace(item)))
+		}
+		return values
+	case schema.Type.Is(openapi3.TypeObject):
+		return jsonValue(raw)
+	}
+	return raw
+}
+
+func jsonValue(raw string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return raw
+	}
+	return value
+}
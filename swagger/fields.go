@@ -0,0 +1,173 @@
+package swagger
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/fatih/structtag"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sparkle-technologies/swagger_gin/router"
+)
+
+type structField struct {
+	reflect.StructField
+	Value reflect.Value
+}
+
+// structFields list the exported fields of a struct following go embedding semantics,
+// embedded structs without a name in tagKey are promoted into the outer struct. With flatten
+// unset their types are returned instead of their fields, so they can be referenced with allOf
+func structFields(
+	type_ reflect.Type,
+	value_ reflect.Value,
+	tagKey string,
+	flatten bool,
+) (fields []structField, embedded []reflect.Type) {
+	var promoted []structField
+	for i := 0; i < type_.NumField(); i++ {
+		field := type_.Field(i)
+		if isPromoted(field, tagKey) {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if !flatten {
+				embedded = append(embedded, embeddedType)
+				continue
+			}
+			embeddedFields, _ := structFields(embeddedType, reflect.New(embeddedType).Elem(), tagKey, flatten)
+			promoted = append(promoted, embeddedFields...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, structField{StructField: field, Value: value_.Field(i)})
+	}
+
+	// fields of the outer struct shadow the promoted ones
+	names := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		names[field.Name] = struct{}{}
+	}
+	for _, field := range promoted {
+		if _, ok := names[field.Name]; !ok {
+			names[field.Name] = struct{}{}
+			fields = append(fields, field)
+		}
+	}
+	return fields, embedded
+}
+
+func isPromoted(field reflect.StructField, tagKey string) bool {
+	if !field.Anonymous {
+		return false
+	}
+	type_ := field.Type
+	if type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	if type_.Kind() != reflect.Struct {
+		return false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get(tagKey), ",")
+	return name == ""
+}
+
+// fieldTag return the name of the field under tagKey and whether it is omitted when empty,
+// fields tagged "-" are ignored by encoding/json and gin binding
+func fieldTag(field reflect.StructField, tagKey string) (name string, omitEmpty, skip bool) {
+	tag := field.Tag.Get(tagKey)
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, omitEmpty, false
+}
+
+// isRequiredField report whether the field is always present, requests are checked by
+// the validator and responses always hold the fields encoding/json doesn't omit
+func isRequiredField(field reflect.StructField, tags *structtag.Tags, omitEmpty, isRequest bool) bool {
+	if isRequest {
+		return isRequiredTags(tags)
+	}
+	return !omitEmpty && field.Type.Kind() != reflect.Ptr
+}
+
+// isNullable report whether encoding/json writes null for the nil value of type_
+func isNullable(type_ reflect.Type, omitEmpty bool) bool {
+	switch type_.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return !omitEmpty
+	}
+	return false
+}
+
+// schemaModifier set a keyword on the schema of a property
+type schemaModifier func(schema *openapi3.Schema)
+
+// fieldModifiers return the modifiers of a property, from its type and the openapi tag,
+// e.g. openapi:"readonly,deprecated"
+func fieldModifiers(field reflect.StructField, omitEmpty bool) []schemaModifier {
+	var modifiers []schemaModifier
+	if isNullable(field.Type, omitEmpty) {
+		modifiers = append(modifiers, func(schema *openapi3.Schema) { schema.Nullable = true })
+	}
+	for _, option := range router.OpenAPIOptions(field) {
+		switch option {
+		case "readonly":
+			modifiers = append(modifiers, func(schema *openapi3.Schema) { schema.ReadOnly = true })
+		case "writeonly":
+			modifiers = append(modifiers, func(schema *openapi3.Schema) { schema.WriteOnly = true })
+		case "deprecated":
+			modifiers = append(modifiers, func(schema *openapi3.Schema) { schema.Deprecated = true })
+		}
+	}
+	return modifiers
+}
+
+// modifySchemaRef apply modifiers to schemaRef, siblings of $ref are ignored so refs are wrapped in allOf
+func modifySchemaRef(schemaRef *openapi3.SchemaRef, modifiers ...schemaModifier) *openapi3.SchemaRef {
+	if schemaRef.Ref != "" {
+		schemaRef = openapi3.NewSchemaRef("", &openapi3.Schema{AllOf: openapi3.SchemaRefs{schemaRef}})
+	}
+	for _, modifier := range modifiers {
+		modifier(schemaRef.Value)
+	}
+	return schemaRef
+}
+
+// hasBodyFields report whether a request model has fields bound from the body
+func hasBodyFields(type_ reflect.Type) bool {
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	if type_.Kind() != reflect.Struct {
+		return true
+	}
+	fields, _ := structFields(type_, reflect.New(type_).Elem(), JSON, true)
+	for _, field := range fields {
+		if _, _, skip := fieldTag(field.StructField, JSON); !skip && !router.IsParameter(field.StructField) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFormContentType report whether bodies of contentType are bound with the form tags
+func isFormContentType(contentType string) bool {
+	switch router.ParseMediaType(contentType) {
+	case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package swagger
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// NamingStrategy return the component name of a go type
+type NamingStrategy func(type_ reflect.Type) string
+
+var (
+	versionRegex     = regexp.MustCompile(`^v[0-9]+$`)
+	invalidNameRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+)
+
+// ShortName name components by their bare type name,
+// e.g. user.Response -> Response, Page[pkg.Item] -> PageOfItem
+func ShortName(type_ reflect.Type) string {
+	return typeName(type_.PkgPath(), type_.Name(), false)
+}
+
+// QualifiedName prefix component names with their package name,
+// e.g. user.Response -> UserResponse, Page[pkg.Item] -> PaginationPageOfPkgItem
+func QualifiedName(type_ reflect.Type) string {
+	return typeName(type_.PkgPath(), type_.Name(), true)
+}
+
+// schemaName return the provisional component name of type_, names colliding with another type
+// are qualified with the package name, or panic when StrictSchemaNames is set. nameComponents
+// settles the final names once all the types are known
+func (swagger *Swagger) schemaName(type_ reflect.Type) string {
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	if name, ok := swagger.typeNames[type_]; ok {
+		return name
+	}
+
+	naming := swagger.SchemaNaming
+	if naming == nil {
+		naming = ShortName
+	}
+	name := naming(type_)
+	if other, ok := swagger.schemaNames[name]; ok && other != type_ {
+		if swagger.StrictSchemaNames {
+			panic(fmt.Errorf("schema name '%s' of %s collides with %s", name, type_, other))
+		}
+		name = QualifiedName(type_)
+		for i := 2; swagger.schemaNames[name] != nil; i++ {
+			name = fmt.Sprintf("%s%d", QualifiedName(type_), i)
+		}
+	}
+
+	swagger.typeNames[type_] = name
+	swagger.schemaNames[name] = type_
+	return name
+}
+
+// typeName render a type name as reported by reflect, generic type arguments are
+// rendered as "Of" followed by their names joined by "And"
+func typeName(pkgPath, name string, qualified bool) string {
+	base, args := splitTypeArgs(name)
+	if qualified && pkgPath != "" {
+		base = exportName(packageName(pkgPath)) + base
+	}
+	if len(args) > 0 {
+		argNames := make([]string, 0, len(args))
+		for _, arg := range args {
+			argNames = append(argNames, typeArgName(arg, qualified))
+		}
+		base += "Of" + strings.Join(argNames, "And")
+	}
+	return invalidNameRegex.ReplaceAllString(base, "")
+}
+
+// typeArgName render a type argument, e.g. "[]github.com/foo/pkg.Item" -> "ItemList"
+func typeArgName(arg string, qualified bool) string {
+	arg = strings.TrimLeft(arg, "*")
+	if elem, ok := strings.CutPrefix(arg, "[]"); ok {
+		return typeArgName(elem, qualified) + "List"
+	}
+	if rest, ok := strings.CutPrefix(arg, "map["); ok {
+		if end := closingBracket(rest); end >= 0 {
+			return "MapOf" + typeArgName(rest[:end], qualified) + "To" + typeArgName(rest[end+1:], qualified)
+		}
+	}
+
+	base, _ := splitTypeArgs(arg)
+	pkgPath := ""
+	if i := strings.LastIndex(base, "."); i >= 0 {
+		pkgPath = base[:i]
+		arg = arg[i+1:]
+	}
+	return exportName(typeName(pkgPath, arg, qualified))
+}
+
+// splitTypeArgs split a generic type name into its base name and type arguments
+func splitTypeArgs(name string) (string, []string) {
+	start := strings.Index(name, "[")
+	if start < 0 || !strings.HasSuffix(name, "]") {
+		return name, nil
+	}
+
+	var args []string
+	depth, last := 0, start+1
+	for i := start + 1; i < len(name)-1; i++ {
+		switch name[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(name[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	args = append(args, strings.TrimSpace(name[last:len(name)-1]))
+	return name[:start], args
+}
+
+// closingBracket return the index of the "]" closing an already opened "["
+func closingBracket(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// packageName guess the package name from its import path, e.g. "github.com/foo/bar/v2" -> "bar"
+func packageName(pkgPath string) string {
+	segments := strings.Split(pkgPath, "/")
+	name := segments[len(segments)-1]
+	if versionRegex.MatchString(name) && len(segments) > 1 {
+		name = segments[len(segments)-2]
+	}
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
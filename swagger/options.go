@@ -1,6 +1,8 @@
 package swagger
 
 import (
+	"reflect"
+
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"github.com/sparkle-technologies/swagger_gin/router"
@@ -85,3 +87,41 @@ func RedocOptions(options map[string]interface{}) Option {
 		swagger.RedocOptions = options
 	}
 }
+
+// EmbeddedAllOf reference embedded structs with allOf instead of flattening their fields
+func EmbeddedAllOf() Option {
+	return func(swagger *Swagger) {
+		swagger.EmbeddedAllOf = true
+	}
+}
+
+// SchemaNaming set the naming strategy of components
+func SchemaNaming(naming NamingStrategy) Option {
+	return func(swagger *Swagger) {
+		swagger.SchemaNaming = naming
+	}
+}
+
+// StrictSchemaNames panic on colliding component names instead of qualifying them
+func StrictSchemaNames() Option {
+	return func(swagger *Swagger) {
+		swagger.StrictSchemaNames = true
+	}
+}
+
+// StrictPathParams panic when path params and uri fields don't match instead of fixing the docs
+func StrictPathParams() Option {
+	return func(swagger *Swagger) {
+		swagger.StrictPathParams = true
+	}
+}
+
+// TypeSchema document values of type_ with schema, for types that can't implement SchemaProvider
+func TypeSchema(type_ reflect.Type, schema *openapi3.Schema) Option {
+	return func(swagger *Swagger) {
+		if swagger.TypeSchemas == nil {
+			swagger.TypeSchemas = make(map[reflect.Type]*openapi3.Schema)
+		}
+		swagger.TypeSchemas[type_] = schema
+	}
+}
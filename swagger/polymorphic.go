@@ -0,0 +1,44 @@
+package swagger
+
+import (
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/sparkle-technologies/swagger_gin/router"
+)
+
+// getPolymorphicRef return the ref of the oneOf component of an interface registered
+// with router.RegisterPolymorphic
+func (swagger *Swagger) getPolymorphicRef(type_ reflect.Type, isRequest bool) (string, bool) {
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	if _, ok := router.GetPolymorphic(type_); !ok {
+		return "", false
+	}
+	return swagger.componentRef(type_, isRequest), true
+}
+
+// getPolymorphicSchema build a oneOf of the implementations, mapped by their discriminator value
+func (swagger *Swagger) getPolymorphicSchema(p *router.Polymorphic, isRequest bool) *openapi3.Schema {
+	schema := openapi3.NewOneOfSchema()
+	schema.Discriminator = &openapi3.Discriminator{
+		PropertyName: p.Discriminator,
+		Mapping:      make(map[string]string, len(p.Types)),
+	}
+	for _, value := range sortedKeys(p.Types) {
+		type_ := p.Types[value]
+		ref := swagger.componentRef(type_, isRequest)
+		schema.OneOf = append(schema.OneOf, openapi3.NewSchemaRef(ref, nil))
+		schema.Discriminator.Mapping[value] = ref
+	}
+	return schema
+}
+
+// getSchemaByType is getSchemaByValue for types whose zero value loses the type, e.g. interfaces
+func (swagger *Swagger) getSchemaByType(type_ reflect.Type, isRequest bool) (string, *openapi3.Schema) {
+	if ref, ok := swagger.getPolymorphicRef(type_, isRequest); ok {
+		return ref, nil
+	}
+	return swagger.getSchemaByValue(reflect.New(type_).Elem().Interface(), isRequest)
+}
@@ -0,0 +1,133 @@
+package swagger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SchemaProvider is implemented by types describing their own schema
+type SchemaProvider interface {
+	OpenAPISchema() *openapi3.Schema
+}
+
+var schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+
+// defaultTypeSchemas document std types whose go representation doesn't match their encoding,
+// sql.Null* types are documented as the nullable value they wrap
+func defaultTypeSchemas() map[reflect.Type]*openapi3.Schema {
+	duration := openapi3.NewInt64Schema()
+	duration.Description = "duration in nanoseconds"
+	var zero float64
+	return map[reflect.Type]*openapi3.Schema{
+		reflect.TypeOf(json.RawMessage{}): openapi3.NewSchema(),
+		reflect.TypeOf(time.Duration(0)):  duration,
+		reflect.TypeOf(sql.NullString{}):  openapi3.NewStringSchema().WithNullable(),
+		reflect.TypeOf(sql.NullInt64{}):   openapi3.NewInt64Schema().WithNullable(),
+		reflect.TypeOf(sql.NullInt32{}):   openapi3.NewInt32Schema().WithNullable(),
+		reflect.TypeOf(sql.NullInt16{}):   openapi3.NewIntegerSchema().WithNullable(),
+		reflect.TypeOf(sql.NullByte{}):    openapi3.NewIntegerSchema().WithMin(zero).WithMax(255).WithNullable(),
+		reflect.TypeOf(sql.NullFloat64{}): openapi3.NewFloat64Schema().WithFormat("double").WithNullable(),
+		reflect.TypeOf(sql.NullBool{}):    openapi3.NewBoolSchema().WithNullable(),
+		reflect.TypeOf(sql.NullTime{}):    openapi3.NewDateTimeSchema().WithNullable(),
+	}
+}
+
+// getCustomSchema return the schema of type_ from TypeSchemas or SchemaProvider,
+// it is consulted before the built-in type switch
+func (swagger *Swagger) getCustomSchema(type_ reflect.Type) (*openapi3.Schema, bool) {
+	if type_ == nil {
+		return nil, false
+	}
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+
+	var schema *openapi3.Schema
+	if s, ok := swagger.TypeSchemas[type_]; ok {
+		schema = s
+	} else if type_.Implements(schemaProviderType) {
+		schema = reflect.New(type_).Elem().Interface().(SchemaProvider).OpenAPISchema()
+	} else if reflect.PointerTo(type_).Implements(schemaProviderType) {
+		schema = reflect.New(type_).Interface().(SchemaProvider).OpenAPISchema()
+	}
+	if schema == nil {
+		return nil, false
+	}
+
+	// callers set the description and constraints of the field on the schema, down to its items
+	return copySchema(schema), true
+}
+
+// copySchema deep copy the parts of schema that field modifiers write to
+func copySchema(schema *openapi3.Schema) *openapi3.Schema {
+	if schema == nil {
+		return nil
+	}
+	copied := *schema
+	copied.OneOf = copySchemaRefs(schema.OneOf)
+	copied.AnyOf = copySchemaRefs(schema.AnyOf)
+	copied.AllOf = copySchemaRefs(schema.AllOf)
+	copied.Not = copySchemaRef(schema.Not)
+	copied.Items = copySchemaRef(schema.Items)
+	copied.AdditionalProperties.Schema = copySchemaRef(schema.AdditionalProperties.Schema)
+	if schema.Properties != nil {
+		copied.Properties = make(openapi3.Schemas, len(schema.Properties))
+		for name, property := range schema.Properties {
+			copied.Properties[name] = copySchemaRef(property)
+		}
+	}
+	if schema.Extensions != nil {
+		copied.Extensions = make(map[string]interface{}, len(schema.Extensions))
+		for key, val := range schema.Extensions {
+			copied.Extensions[key] = val
+		}
+	}
+	if schema.Discriminator != nil {
+		discriminator := *schema.Discriminator
+		discriminator.Mapping = make(map[string]string, len(schema.Discriminator.Mapping))
+		for value, ref := range schema.Discriminator.Mapping {
+			discriminator.Mapping[value] = ref
+		}
+		copied.Discriminator = &discriminator
+	}
+	copied.Enum = append([]interface{}(nil), schema.Enum...)
+	copied.Required = append([]string(nil), schema.Required...)
+	copied.Min = copyPointer(schema.Min)
+	copied.Max = copyPointer(schema.Max)
+	copied.MultipleOf = copyPointer(schema.MultipleOf)
+	copied.MaxLength = copyPointer(schema.MaxLength)
+	copied.MaxItems = copyPointer(schema.MaxItems)
+	copied.MaxProps = copyPointer(schema.MaxProps)
+	return &copied
+}
+
+// copySchemaRef copy an inline schema, refs to components are kept as they are
+func copySchemaRef(schemaRef *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if schemaRef == nil || schemaRef.Ref != "" {
+		return schemaRef
+	}
+	return &openapi3.SchemaRef{Value: copySchema(schemaRef.Value)}
+}
+
+func copySchemaRefs(schemaRefs openapi3.SchemaRefs) openapi3.SchemaRefs {
+	if schemaRefs == nil {
+		return nil
+	}
+	copied := make(openapi3.SchemaRefs, len(schemaRefs))
+	for i, schemaRef := range schemaRefs {
+		copied[i] = copySchemaRef(schemaRef)
+	}
+	return copied
+}
+
+func copyPointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	copied := *p
+	return &copied
+}
@@ -9,9 +9,12 @@ import (
 	"net/url"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sparkle-technologies/swagger_gin/problem"
 	"github.com/sparkle-technologies/swagger_gin/router"
 	"github.com/sparkle-technologies/swagger_gin/security"
 
@@ -24,6 +27,8 @@ import (
 
 const (
 	DEFAULT     = "default"
+	EXAMPLE     = "example"
+	EXAMPLES    = "examples"
 	VALIDATE    = "validate"
 	DESCRIPTION = "description"
 	QUERY       = "query"
@@ -32,23 +37,38 @@ const (
 	HEADER      = "header"
 	COOKIE      = "cookie"
 	JSON        = "json"
+	OPENAPI     = "openapi"
 )
 
+// schemaKey identify a component built from a go type, request components only hold form fields
+type schemaKey struct {
+	type_   reflect.Type
+	request bool
+}
+
 type Swagger struct {
-	Title          string
-	Description    string
-	Version        string
-	DocsUrl        string
-	RedocUrl       string
-	OpenAPIUrl     string
-	Routers        map[*gin.RouterGroup]map[string]map[string]*router.Router
-	Servers        openapi3.Servers
-	TermsOfService string
-	Contact        *openapi3.Contact
-	License        *openapi3.License
-	OpenAPI        *openapi3.T
-	SwaggerOptions map[string]interface{}
-	RedocOptions   map[string]interface{}
+	Title             string
+	Description       string
+	Version           string
+	DocsUrl           string
+	RedocUrl          string
+	OpenAPIUrl        string
+	Routers           map[*gin.RouterGroup]map[string]map[string]*router.Router
+	Servers           openapi3.Servers
+	TermsOfService    string
+	Contact           *openapi3.Contact
+	License           *openapi3.License
+	OpenAPI           *openapi3.T
+	SwaggerOptions    map[string]interface{}
+	RedocOptions      map[string]interface{}
+	EmbeddedAllOf     bool
+	SchemaNaming      NamingStrategy
+	StrictSchemaNames bool
+	StrictPathParams  bool
+	schemaTypes       map[schemaKey]string
+	typeNames         map[reflect.Type]string
+	schemaNames       map[string]reflect.Type
+	TypeSchemas       map[reflect.Type]*openapi3.Schema
 }
 
 func New(title, description, version string, options ...Option) *Swagger {
@@ -59,6 +79,7 @@ func New(title, description, version string, options ...Option) *Swagger {
 		DocsUrl:     "/docs",
 		RedocUrl:    "/redoc",
 		OpenAPIUrl:  "/openapi.json",
+		TypeSchemas: defaultTypeSchemas(),
 	}
 	for _, option := range options {
 		option(swagger)
@@ -66,16 +87,32 @@ func New(title, description, version string, options ...Option) *Swagger {
 	return swagger
 }
 
+// getSecurityRequirements document the securities of a route, which are all required, as the
+// alternative sets of schemes that authorize it, the schemes carrying scopes, oauth2 and
+// openIdConnect, require the route's scopes
 func (swagger *Swagger) getSecurityRequirements(
 	securities []security.ISecurity,
+	scopes []string,
 ) *openapi3.SecurityRequirements {
 	securityRequirements := openapi3.NewSecurityRequirements()
-	for _, s := range securities {
-		provide := s.Provider()
-		swagger.OpenAPI.Components.SecuritySchemes[provide] = &openapi3.SecuritySchemeRef{
-			Value: s.Scheme(),
+	if len(securities) == 0 {
+		return securityRequirements
+	}
+	for _, schemes := range security.Requirements(security.AllOf(securities...)) {
+		requirement := openapi3.NewSecurityRequirement()
+		for _, s := range schemes {
+			provide := s.Provider()
+			scheme := s.Scheme()
+			swagger.OpenAPI.Components.SecuritySchemes[provide] = &openapi3.SecuritySchemeRef{
+				Value: scheme,
+			}
+			if scheme.Type == "oauth2" || scheme.Type == "openIdConnect" {
+				requirement.Authenticate(provide, scopes...)
+			} else {
+				requirement.Authenticate(provide)
+			}
 		}
-		securityRequirements.With(openapi3.NewSecurityRequirement().Authenticate(provide))
+		securityRequirements.With(requirement)
 	}
 	return securityRequirements
 }
@@ -113,10 +150,22 @@ func (swagger *Swagger) getBasicSchemaByType(typ reflect.Kind) *openapi3.Schema
 	return schema
 }
 
+// getSchemaByKind document named types of basic kinds, e.g. type Status string
+func (swagger *Swagger) getSchemaByKind(kind reflect.Kind) *openapi3.Schema {
+	if schema := swagger.getBasicSchemaByType(kind); schema != nil {
+		return schema
+	}
+	return openapi3.NewObjectSchema()
+}
+
 func (swagger *Swagger) getSchemaByValue(
 	t interface{},
 	request bool,
 ) (ref string, schema *openapi3.Schema) {
+	if custom, ok := swagger.getCustomSchema(reflect.TypeOf(t)); ok {
+		return "", custom
+	}
+
 	var m = float64(0)
 	switch val := t.(type) {
 	case int, int8, int16:
@@ -162,7 +211,7 @@ func (swagger *Swagger) getSchemaByValue(
 	case EnumAble:
 		valEnums := val.Enums()
 		typ_ := reflect.TypeOf(val)
-		name := typ_.Name()
+		name := swagger.schemaName(typ_)
 		enums := make([]interface{}, 0, len(valEnums))
 		names := make([]string, 0, len(valEnums))
 		valMap := make(map[interface{}]struct{}, len(valEnums))
@@ -211,9 +260,8 @@ func (swagger *Swagger) getRequestSchemaByModel(model interface{}) (string, *ope
 	ref := ""
 	type_ := reflect.TypeOf(model)
 	if type_ == nil {
-		schema := openapi3.NewObjectSchema()
-		schema.Items = &openapi3.SchemaRef{Value: openapi3.NewObjectSchema()}
-		return ref, schema
+		// interface{} holds any value
+		return ref, &openapi3.Schema{}
 	}
 	value_ := reflect.ValueOf(model)
 	schema := openapi3.NewObjectSchema()
@@ -227,54 +275,22 @@ func (swagger *Swagger) getRequestSchemaByModel(model interface{}) (string, *ope
 	if value_.Kind() == reflect.Ptr {
 		value_ = value_.Elem()
 	}
-	if type_.Kind() == reflect.Struct {
-		for i := 0; i < type_.NumField(); i++ {
-			field := type_.Field(i)
-			fieldType := field.Type
-			fieldvalue := value_.Field(i)
-			tags, err := structtag.Parse(string(field.Tag))
-			if err != nil {
-				panic(err)
-			}
-			tag, err := tags.Get(FORM)
-			if err != nil {
-				continue
-			}
-
-			// dereference
-			if fieldType.Kind() == reflect.Ptr {
-				fieldType = fieldType.Elem()
-				if fieldvalue.IsNil() {
-					fieldvalue = reflect.New(fieldType)
-				}
-			}
-
-			if fieldvalue.Kind() == reflect.Ptr {
-				fieldvalue = fieldvalue.Elem()
-			}
-
-			fieldRef, fieldSchema := swagger.getSchemaByValue(fieldvalue.Interface(), true)
-			descriptionTag, err := tags.Get(DESCRIPTION)
-			if err == nil {
-				fieldSchema.Description = descriptionTag.Name
-			}
-
-			defaultTag, err := tags.Get(DEFAULT)
-			if err == nil {
-				fieldSchema.Default = defaultTag.Name
-			}
-			schema.Properties[tag.Name] = openapi3.NewSchemaRef(fieldRef, fieldSchema)
-		}
-	} else if type_.Kind() == reflect.Slice {
+	if polymorphicRef, ok := swagger.getPolymorphicRef(type_, true); ok {
+		ref = polymorphicRef
+	} else if type_.Kind() == reflect.Struct && type_.Name() != "" {
+		ref = swagger.componentRef(type_, true)
+	} else if type_.Kind() == reflect.Struct {
+		schema = swagger.getStructSchema(type_, value_, true, JSON)
+	} else if type_.Kind() == reflect.Slice || type_.Kind() == reflect.Array {
 		schema = openapi3.NewArraySchema()
-		ref, refSchema := swagger.getRequestSchemaByModel(reflect.New(type_.Elem()).Elem().Interface())
-		schema.Items = &openapi3.SchemaRef{Ref: ref, Value: refSchema}
+		schema.Items = openapi3.NewSchemaRef(swagger.getSchemaByType(type_.Elem(), true))
 	} else if type_.Kind() == reflect.Map {
 		schema = openapi3.NewObjectSchema()
-		ref, refSchema := swagger.getRequestSchemaByModel(reflect.New(type_.Elem()).Elem().Interface())
-		schema.Items = &openapi3.SchemaRef{Ref: ref, Value: refSchema}
+		schema.AdditionalProperties = openapi3.AdditionalProperties{
+			Schema: openapi3.NewSchemaRef(swagger.getSchemaByType(type_.Elem(), true)),
+		}
 	} else {
-		ref, schema = swagger.getSchemaByValue(model, true)
+		schema = swagger.getSchemaByKind(type_.Kind())
 	}
 	return ref, schema
 }
@@ -300,181 +316,263 @@ func (swagger *Swagger) getComponentByModel(model any, isRequest bool) {
 		value_ = value_.Elem()
 	}
 
+	// only named structs and polymorphic interfaces are components, other models are inlined
+	_, polymorphic := router.GetPolymorphic(type_)
+	if !polymorphic && (type_.Kind() != reflect.Struct || type_.Name() == "") {
+		return
+	}
+
+	key := schemaKey{type_: type_, request: isRequest}
+	if _, ok := swagger.schemaTypes[key]; ok {
+		// already built, or being built further up the stack for a recursive type
+		return
+	}
+
+	if swagger.OpenAPI.Components.Schemas == nil {
+		swagger.OpenAPI.Components.Schemas = make(openapi3.Schemas)
+	}
+
 	// openapi3.Schemas k -> struct name = title -> struct name
-	// get struct name from request.SchemaName
-	schemaRef := &openapi3.SchemaRef{}
-	schemaRef.Value = openapi3.NewObjectSchema()
+	// reserve the component before walking the fields, so back-edges can $ref it
+	name := swagger.schemaName(type_)
+	if isRequest {
+		name += requestSuffix
+	}
+	swagger.schemaTypes[key] = name
+	schemaRef := openapi3.NewSchemaRef("", openapi3.NewObjectSchema())
+	schemaRef.Value.Title = name
+	swagger.OpenAPI.Components.Schemas[name] = schemaRef
 
 	// schemaRef is the outer field
 	// if it is a struct, handle its fields
-	if type_.Kind() == reflect.Struct {
-		for i := 0; i < type_.NumField(); i++ {
-			field := type_.Field(i)
-			fieldType := field.Type
-			fieldvalue := value_.Field(i)
-			tags, err := structtag.Parse(string(field.Tag))
-			if err != nil {
-				panic(err)
-			}
-			tag, err := tags.Get(FORM)
-			if err != nil && isRequest {
-				// only request body need to be added to components
-				continue
-			}
+	if p, ok := router.GetPolymorphic(type_); ok {
+		schemaRef.Value = swagger.getPolymorphicSchema(p, isRequest)
+		schemaRef.Value.Title = name
+	} else if custom, ok := swagger.getCustomSchema(type_); ok {
+		schemaRef.Value = custom
+		schemaRef.Value.Title = name
+	} else if type_.Kind() == reflect.Struct {
+		schemaRef.Value = swagger.getStructSchema(type_, value_, isRequest, JSON)
+		schemaRef.Value.Title = name
+	}
+}
 
-			// dereference
-			if fieldType.Kind() == reflect.Ptr {
-				fieldType = fieldType.Elem()
-				if fieldvalue.IsNil() {
-					fieldvalue = reflect.New(fieldType)
-				}
-			}
+// getStructSchema build the object schema of a struct with the field names of tagKey, promoted fields
+// of embedded structs are flattened into it, or referenced through allOf when EmbeddedAllOf is set.
+// Request schemas describe the body only, fields bound from parameters are left out
+func (swagger *Swagger) getStructSchema(
+	type_ reflect.Type,
+	value_ reflect.Value,
+	isRequest bool,
+	tagKey string,
+) *openapi3.Schema {
+	fields, embedded := structFields(type_, value_, tagKey, !swagger.EmbeddedAllOf)
+
+	schemaRef := openapi3.NewSchemaRef("", openapi3.NewObjectSchema())
+	modifiers := make(map[string][]schemaModifier)
+	for _, f := range fields {
+		field := f.StructField
+		fieldType := field.Type
+		fieldvalue := f.Value
+		tags, err := structtag.Parse(string(field.Tag))
+		if err != nil {
+			panic(err)
+		}
+		if isRequest && router.IsParameter(field) {
+			continue
+		}
+		fieldName, omitEmpty, skip := fieldTag(field, tagKey)
+		if skip {
+			continue
+		}
+		if isRequiredField(field, tags, omitEmpty, isRequest) {
+			schemaRef.Value.Required = append(schemaRef.Value.Required, fieldName)
+		}
+		modifiers[fieldName] = fieldModifiers(field, omitEmpty)
 
-			if fieldvalue.Kind() == reflect.Ptr {
-				fieldvalue = fieldvalue.Elem()
+		// dereference
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			if fieldvalue.IsNil() {
+				fieldvalue = reflect.New(fieldType)
 			}
+		}
 
-			var fieldName = field.Name
-			if isRequest {
-				fieldName = tag.Name
-			} else if jsonTag, err := tags.Get(JSON); err == nil && jsonTag != nil {
-				fieldName = jsonTag.Name
-			}
+		if fieldvalue.Kind() == reflect.Ptr {
+			fieldvalue = fieldvalue.Elem()
+		}
 
-			if isRequiredTags(tags) {
-				schemaRef.Value.Required = append(schemaRef.Value.Required, fieldName)
+		if fieldSchema, ok := swagger.getCustomSchema(fieldType); ok {
+			descriptionTag, err := tags.Get(DESCRIPTION)
+			if err == nil {
+				fieldSchema.Description = descriptionTag.Name
 			}
-
-			if fieldType.Kind() == reflect.Struct {
-				if fieldType.Name() == "Time" {
-					fieldRef, fieldSchema := swagger.getSchemaByValue(
-						fieldvalue.Interface(),
-						isRequest,
-					)
-					fieldSchema.Format = "date-time"
-					fieldSchema.Type = &openapi3.Types{openapi3.TypeString}
-
-					schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(
-						fieldRef,
-						fieldSchema,
-					)
-					continue
-				} else if !swagger.checkSchemaExist(fieldType.Name()) {
-					swagger.getComponentByModel(reflect.New(fieldType).Elem().Interface(), isRequest)
-				}
-				//schemaRef.Ref = generateRefName(field.Type.Name())
-				fieldSchemaRef := openapi3.NewSchemaRef(generateRefName(fieldType.Name()), nil)
-				schemaRef.Value.Properties[fieldName] = fieldSchemaRef
-			} else if fieldType.Kind() == reflect.Slice {
-				// check if type.Elem() if built-in type
-				fieldRef, fieldSchema := swagger.getSchemaByValue(fieldvalue.Interface(), isRequest)
-				if !isBuiltinType(fieldType.Elem()) {
-					subFieldValue := reflect.New(fieldType.Elem()).Elem().Interface()
-					subFieldType := reflect.TypeOf(subFieldValue)
-					if subFieldType.Kind() == reflect.Ptr {
-						subFieldType = subFieldType.Elem()
-
-					}
-
-					if !swagger.checkSchemaExist(subFieldType.Name()) {
-						swagger.getComponentByModel(subFieldValue, isRequest)
-					}
-
-					fieldSchemaRef := openapi3.NewSchemaRef(generateRefName(subFieldType.Name()), nil)
-					fieldSchema.Items = fieldSchemaRef
-				} else {
-					descriptionTag, err := tags.Get(DESCRIPTION)
-					if err == nil {
-						fieldSchema.Description = descriptionTag.Name
-					}
-
-					defaultTag, err := tags.Get(DEFAULT)
-					if err == nil {
-						fieldSchema.Default = defaultTag.Name
-					}
-				}
-				schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(fieldRef, fieldSchema)
-			} else if fieldType.Kind() == reflect.Map {
-				// To define a dictionary, use type: object and use the additionalProperties
-				// keyword to specify the type of values in key/value pairs.
-				// the keys must be string
-
-				// get the value type
-				mapValueType := fieldType.Elem()
-				fieldSchema := openapi3.NewObjectSchema()
-
-				var ap openapi3.AdditionalProperties
-
-				if mapValueType.Kind() == reflect.Interface {
-					var b = true
-					ap.Has = &b
-				} else if mapValueType.Kind() == reflect.Struct {
-					if !swagger.checkSchemaExist(fieldType.Elem().Name()) {
-						swagger.getComponentByModel(reflect.New(fieldType.Elem()).Elem().Interface(), isRequest)
-					}
-					ap.Schema = openapi3.NewSchemaRef(generateRefName(fieldType.Elem().Name()), nil)
-				} else {
-					// basic type
-					schema := swagger.getBasicSchemaByType(mapValueType.Kind())
-					ap.Schema = openapi3.NewSchemaRef("", schema)
+			applyValueTags(fieldSchema, tags)
+			applyValidateTags(fieldSchema, tags)
+			schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef("", fieldSchema)
+		} else if fieldType.Kind() == reflect.Struct {
+			if fieldType.Name() == "Time" {
+				fieldRef, fieldSchema := swagger.getSchemaByValue(
+					fieldvalue.Interface(),
+					isRequest,
+				)
+				fieldSchema.Format = "date-time"
+				fieldSchema.Type = &openapi3.Types{openapi3.TypeString}
+				if descriptionTag, err := tags.Get(DESCRIPTION); err == nil {
+					fieldSchema.Description = descriptionTag.Name
 				}
-				fieldSchema.AdditionalProperties = ap
-				schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef("", fieldSchema)
-			} else if fieldType.Kind() == reflect.Interface {
-				schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef("", openapi3.NewObjectSchema())
-			} else {
-
-				// getSchemaByValue can't distinguish any with map[string]any and []any
-				// all of them are nil in func
-				fieldRef, fieldSchema := swagger.getSchemaByValue(fieldvalue.Interface(), isRequest)
+				applyValueTags(fieldSchema, tags)
+				applyValidateTags(fieldSchema, tags)
 
+				schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(
+					fieldRef,
+					fieldSchema,
+				)
+				continue
+			} else if fieldType.Name() == "" {
+				// anonymous struct
+				schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(
+					"",
+					swagger.getStructSchema(fieldType, reflect.New(fieldType).Elem(), isRequest, tagKey),
+				)
+				continue
+			}
+			fieldSchemaRef := openapi3.NewSchemaRef(swagger.componentRef(fieldType, isRequest), nil)
+			schemaRef.Value.Properties[fieldName] = fieldSchemaRef
+		} else if fieldType.Kind() == reflect.Slice {
+			// named struct items are referenced through getComponentByModel
+			fieldRef, fieldSchema := swagger.getSchemaByValue(fieldvalue.Interface(), isRequest)
+			if isBuiltinType(fieldType.Elem()) {
 				descriptionTag, err := tags.Get(DESCRIPTION)
 				if err == nil {
 					fieldSchema.Description = descriptionTag.Name
 				}
 
-				defaultTag, err := tags.Get(DEFAULT)
-				if err == nil {
-					fieldSchema.Default = defaultTag.Name
-				}
-				schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(fieldRef, fieldSchema)
+				applyValueTags(fieldSchema, tags)
+			}
+			applyValidateTags(fieldSchema, tags)
+			schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(fieldRef, fieldSchema)
+		} else if fieldType.Kind() == reflect.Map {
+			// To define a dictionary, use type: object and use the additionalProperties
+			// keyword to specify the type of values in key/value pairs.
+			// the keys must be string
+
+			// get the value type
+			mapValueType := fieldType.Elem()
+			if mapValueType.Kind() == reflect.Ptr {
+				mapValueType = mapValueType.Elem()
+			}
+			fieldSchema := openapi3.NewObjectSchema()
+
+			var ap openapi3.AdditionalProperties
+
+			if polymorphicRef, ok := swagger.getPolymorphicRef(mapValueType, isRequest); ok {
+				ap.Schema = openapi3.NewSchemaRef(polymorphicRef, nil)
+			} else if mapValueType.Kind() == reflect.Interface {
+				var b = true
+				ap.Has = &b
+			} else if mapValueType.Kind() == reflect.Struct && mapValueType.Name() == "" {
+				ap.Schema = openapi3.NewSchemaRef(
+					"",
+					swagger.getStructSchema(mapValueType, reflect.New(mapValueType).Elem(), isRequest, tagKey),
+				)
+			} else if mapValueType.Kind() == reflect.Struct {
+				ap.Schema = openapi3.NewSchemaRef(swagger.componentRef(mapValueType, isRequest), nil)
+			} else if isBuiltinType(mapValueType) {
+				// basic type
+				schema := swagger.getBasicSchemaByType(mapValueType.Kind())
+				ap.Schema = openapi3.NewSchemaRef("", schema)
+			} else {
+				ap.Schema = openapi3.NewSchemaRef(
+					swagger.getSchemaByValue(reflect.New(mapValueType).Elem().Interface(), isRequest),
+				)
+			}
+			fieldSchema.AdditionalProperties = ap
+			applyValidateTags(fieldSchema, tags)
+			schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef("", fieldSchema)
+		} else if polymorphicRef, ok := swagger.getPolymorphicRef(fieldType, isRequest); ok {
+			schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(polymorphicRef, nil)
+		} else if fieldType.Kind() == reflect.Interface {
+			schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef("", openapi3.NewObjectSchema())
+		} else {
+
+			// getSchemaByValue can't distinguish any with map[string]any and []any
+			// all of them are nil in func
+			fieldRef, fieldSchema := swagger.getSchemaByValue(fieldvalue.Interface(), isRequest)
+
+			descriptionTag, err := tags.Get(DESCRIPTION)
+			if err == nil {
+				fieldSchema.Description = descriptionTag.Name
+			}
+
+			if fieldRef == "" {
+				applyValueTags(fieldSchema, tags)
+				applyValidateTags(fieldSchema, tags)
 			}
+			schemaRef.Value.Properties[fieldName] = openapi3.NewSchemaRef(fieldRef, fieldSchema)
 		}
 	}
 
-	if swagger.OpenAPI.Components.Schemas == nil {
-		swagger.OpenAPI.Components.Schemas = make(openapi3.Schemas)
+	for fieldName, fieldModifiers := range modifiers {
+		if property, ok := schemaRef.Value.Properties[fieldName]; ok && len(fieldModifiers) > 0 {
+			schemaRef.Value.Properties[fieldName] = modifySchemaRef(property, fieldModifiers...)
+		}
 	}
 
-	schemaRef.Value.Title = removePackageName(type_.Name())
-	// if it goes here, the schemaRef has `Value` rather than `Ref`
-	swagger.OpenAPI.Components.Schemas[schemaRef.Value.Title] = schemaRef
+	if len(embedded) == 0 {
+		return schemaRef.Value
+	}
+
+	schema := openapi3.NewAllOfSchema()
+	for _, embeddedType := range embedded {
+		schema.AllOf = append(schema.AllOf, openapi3.NewSchemaRef(swagger.componentRef(embeddedType, isRequest), nil))
+	}
+	schema.AllOf = append(schema.AllOf, schemaRef)
+	return schema
 }
 
 func (swagger *Swagger) getRequestBodyRef(
-	name string,
-	contentType string,
+	type_ reflect.Type,
+	contentTypes []string,
 ) *openapi3.RequestBodyRef {
 	body := &openapi3.RequestBodyRef{
 		Value: openapi3.NewRequestBody(),
 	}
 	body.Value.Required = true
-	if contentType == "" {
-		contentType = binding.MIMEJSON
+	if len(contentTypes) == 0 {
+		contentTypes = []string{binding.MIMEJSON}
 	}
-	schemaRef := openapi3.NewSchemaRef(generateRefName(removePackageName(name)), nil)
+	schemaRef := swagger.getModelSchemaRef(type_, true)
 	body.Value.Content = openapi3.NewContent()
-	body.Value.Content[contentType] = openapi3.NewMediaType().WithSchemaRef(schemaRef)
+	for _, contentType := range contentTypes {
+		mediaType := openapi3.NewMediaType().WithSchemaRef(schemaRef)
+		if isFormContentType(contentType) {
+			mediaType = openapi3.NewMediaType().WithSchema(swagger.getFormSchema(type_))
+		}
+		mediaType.Examples = getMediaTypeExamples(type_)
+		body.Value.Content[contentType] = mediaType
+	}
 	return body
 }
 
+// getFormSchema build the inline schema of form bodies, which follow the form tags
+func (swagger *Swagger) getFormSchema(type_ reflect.Type) *openapi3.Schema {
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	if type_.Kind() != reflect.Struct {
+		_, schema := swagger.getSchemaByValue(reflect.New(type_).Elem().Interface(), true)
+		return schema
+	}
+	return swagger.getStructSchema(type_, reflect.New(type_).Elem(), true, FORM)
+}
+
 func (swagger *Swagger) getResponseSchemaByModel(model interface{}) (string, *openapi3.Schema) {
 	ref := ""
 	type_ := reflect.TypeOf(model)
 	if type_ == nil {
-		schema := openapi3.NewObjectSchema()
-		schema.Items = &openapi3.SchemaRef{Value: openapi3.NewObjectSchema()}
-		return ref, schema
+		// interface{} holds any value
+		return ref, &openapi3.Schema{}
 	}
 	value_ := reflect.ValueOf(model)
 	if type_.Kind() == reflect.Ptr {
@@ -488,76 +586,74 @@ func (swagger *Swagger) getResponseSchemaByModel(model interface{}) (string, *op
 		value_ = value_.Elem()
 	}
 	schema := openapi3.NewObjectSchema()
-	if type_.Kind() == reflect.Struct {
-		ref = generateRefName(type_.Name())
-		for i := 0; i < value_.NumField(); i++ {
-			fieldValue := value_.Field(i)
-			fieldType := value_.Type().Field(i)
-			if fieldType.IsExported() && value_.IsValid() {
-				fieldRef, fieldSchema := swagger.getSchemaByValue(fieldValue.Interface(), false)
-				tags, err := structtag.Parse(string(fieldType.Tag))
-				if err != nil {
-					panic(err)
-				}
-				tag, err := tags.Get("json")
-				if err != nil {
-					continue
-				}
-
-				if isRequiredTags(tags) {
-					schema.Required = append(schema.Required, tag.Name)
-				}
-
-				descriptionTag, err := tags.Get(DESCRIPTION)
-				if err == nil {
-					fieldSchema.Description = descriptionTag.Name
-				}
-				defaultTag, err := tags.Get(DEFAULT)
-				if err == nil {
-					fieldSchema.Default = defaultTag.Name
-				}
-				schema.Properties[tag.Name] = openapi3.NewSchemaRef(fieldRef, fieldSchema)
-			}
-		}
-	} else if type_.Kind() == reflect.Slice {
+	if polymorphicRef, ok := swagger.getPolymorphicRef(type_, false); ok {
+		ref = polymorphicRef
+	} else if type_.Kind() == reflect.Struct && type_.Name() != "" {
+		ref = swagger.componentRef(type_, false)
+	} else if type_.Kind() == reflect.Struct {
+		schema = swagger.getStructSchema(type_, value_, false, JSON)
+	} else if type_.Kind() == reflect.Slice || type_.Kind() == reflect.Array {
 		schema = openapi3.NewArraySchema()
-		ref, schemaRef := swagger.getResponseSchemaByModel(reflect.New(type_.Elem()).Elem().Interface())
-		schema.Items = &openapi3.SchemaRef{Ref: ref, Value: schemaRef}
+		schema.Items = openapi3.NewSchemaRef(swagger.getSchemaByType(type_.Elem(), false))
 	} else if type_.Kind() == reflect.Map {
 		schema = openapi3.NewObjectSchema()
-		ref, schemaRef := swagger.getResponseSchemaByModel(reflect.New(type_.Elem()).Elem().Interface())
-		schema.Items = &openapi3.SchemaRef{Ref: ref, Value: schemaRef}
+		schema.AdditionalProperties = openapi3.AdditionalProperties{
+			Schema: openapi3.NewSchemaRef(swagger.getSchemaByType(type_.Elem(), false)),
+		}
 	} else {
-		ref, schema = swagger.getSchemaByValue(value_.Interface(), false)
+		schema = swagger.getSchemaByKind(type_.Kind())
 	}
 	return ref, schema
 }
 
+// getModelSchemaRef return the schema of a request or response model, named structs and polymorphic
+// interfaces are referenced and any other type is inlined, e.g. the array of []Item references Item
+func (swagger *Swagger) getModelSchemaRef(type_ reflect.Type, isRequest bool) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef(swagger.getSchemaByType(type_, isRequest))
+}
+
 func (swagger *Swagger) getResponsesRef(
 	response router.Response,
-	contentType string,
+	contentTypes []string,
 ) *openapi3.Responses {
-	ret := openapi3.NewResponses()
-	for k, v := range response {
-		type_ := reflect.TypeOf(v.Model)
-		if type_ == nil {
-			continue
-		}
+	if len(response) == 0 {
+		return openapi3.NewResponses()
+	}
+	if len(contentTypes) == 0 {
+		contentTypes = []string{binding.MIMEJSON}
+	}
 
-		schemaRef := openapi3.NewSchemaRef(generateRefName(removePackageName(type_.Name())), nil)
+	ret := openapi3.NewResponsesWithCapacity(len(response))
+	for _, k := range sortedKeys(response) {
+		v := response[k]
+		var content openapi3.Content
+		if len(v.Content) > 0 {
+			content = make(openapi3.Content, len(v.Content))
+			for _, contentType := range sortedKeys(v.Content) {
+				mediaType := v.Content[contentType]
+				content[contentType] = swagger.getMediaType(mediaType.Model, mediaType.Examples)
+			}
+		} else if v.Model != nil {
+			content = make(openapi3.Content, len(contentTypes))
+			for _, contentType := range contentTypes {
+				content[contentType] = swagger.getMediaType(v.Model, nil)
+			}
+		}
 
-		var content = make(openapi3.Content)
-		if contentType == "" {
-			contentType = binding.MIMEJSON
+		headers := swagger.getHeadersByModel(v.HeaderModel)
+		for name, header := range v.Headers {
+			if headers == nil {
+				headers = make(openapi3.Headers, len(v.Headers))
+			}
+			headers[name] = header
 		}
-		content[contentType] = openapi3.NewMediaType().WithSchemaRef(schemaRef)
 
 		description := v.Description
 		ret.Set(k, &openapi3.ResponseRef{
 			Value: &openapi3.Response{
 				Description: &description,
 				Content:     content,
-				Headers:     v.Headers,
+				Headers:     headers,
 			},
 		})
 	}
@@ -565,6 +661,77 @@ func (swagger *Swagger) getResponsesRef(
 	return ret
 }
 
+// getMediaType document a response media type, examples override the ones of ExampleAble models
+func (swagger *Swagger) getMediaType(model interface{}, examples map[string]interface{}) *openapi3.MediaType {
+	mediaType := openapi3.NewMediaType()
+	type_ := reflect.TypeOf(model)
+	if type_ != nil {
+		mediaType.Schema = swagger.getModelSchemaRef(type_, false)
+		mediaType.Examples = getMediaTypeExamples(type_)
+	}
+	for _, name := range sortedKeys(examples) {
+		if mediaType.Examples == nil {
+			mediaType.Examples = make(openapi3.Examples, len(examples))
+		}
+		mediaType.Examples[name] = &openapi3.ExampleRef{Value: openapi3.NewExample(examples[name])}
+	}
+	return mediaType
+}
+
+// addProblemResponses document the problem+json errors written by the default error handler
+// and by security schemes, responses already declared by the route are kept
+func (swagger *Swagger) addProblemResponses(responses *openapi3.Responses, r *router.Router) {
+	var statuses []int
+	if r.ErrorHandler == nil {
+		statuses = append(statuses, http.StatusBadRequest, http.StatusInternalServerError)
+		if r.Model != nil && r.HasBody(r.Method) {
+			statuses = append(statuses, http.StatusUnsupportedMediaType)
+		}
+		if hasResponseContent(r.Response) {
+			statuses = append(statuses, http.StatusNotAcceptable)
+		}
+	}
+	if len(r.Securities) > 0 {
+		statuses = append(statuses, http.StatusUnauthorized)
+		if len(r.Scopes) > 0 {
+			statuses = append(statuses, http.StatusForbidden)
+		}
+	}
+	if len(statuses) == 0 {
+		return
+	}
+
+	problemType := reflect.TypeOf(problem.Problem{})
+	if !swagger.checkSchemaExist(problemType) {
+		swagger.getComponentByModel(problem.Problem{}, false)
+	}
+	for _, status := range statuses {
+		code := strconv.Itoa(status)
+		if responses.Value(code) != nil {
+			continue
+		}
+		schemaRef := openapi3.NewSchemaRef(generateRefName(swagger.schemaName(problemType)), nil)
+		responses.Set(code, &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription(http.StatusText(status)).
+				WithContent(openapi3.Content{
+					problem.MIMEProblemJSON: openapi3.NewMediaType().WithSchemaRef(schemaRef),
+				}),
+		})
+	}
+}
+
+// hasResponseContent report whether a response has a body, which Render negotiates against
+// the Accept header
+func hasResponseContent(response router.Response) bool {
+	for _, item := range response {
+		if item.Model != nil || len(item.Content) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (swagger *Swagger) getParametersByModel(model interface{}) openapi3.Parameters {
 	parameters := openapi3.NewParameters()
 	if model == nil {
@@ -582,9 +749,14 @@ func (swagger *Swagger) getParametersByModel(model interface{}) openapi3.Paramet
 	if value_.Kind() == reflect.Ptr {
 		value_ = value_.Elem()
 	}
-	for i := 0; i < type_.NumField(); i++ {
-		field := type_.Field(i)
-		value := value_.Field(i)
+	if type_.Kind() != reflect.Struct {
+		// slices, maps and primitives are bound from the body only
+		return parameters
+	}
+	fields, _ := structFields(type_, value_, "", true)
+	for _, f := range fields {
+		field := f.StructField
+		value := f.Value
 		tags, err := structtag.Parse(string(field.Tag))
 		if err != nil {
 			panic(err)
@@ -622,49 +794,159 @@ func (swagger *Swagger) getParametersByModel(model interface{}) openapi3.Paramet
 			parameter.Required = true
 		}
 
-		defaultTag, err := tags.Get(DEFAULT)
 		ref, schema := swagger.getSchemaByValue(value.Interface(), true)
-		if err == nil {
-			schema.Default = defaultTag.Name
-		}
-		parameter.Schema = &openapi3.SchemaRef{
-			Value: schema,
+		if ref == "" {
+			applyValueTags(schema, tags)
+			applyValidateTags(schema, tags)
 		}
+		parameter.Examples = getExamples(schema, tags)
+		// components are schemas, they are referenced by the parameter's schema
+		parameter.Schema = openapi3.NewSchemaRef(ref, schema)
 		parameters = append(parameters, &openapi3.ParameterRef{
-			Ref:   ref,
 			Value: parameter,
 		})
 	}
 	return parameters
 }
 
-// /:id -> /{id}
+// getHeadersByModel document the response headers of the fields tagged header of a struct
+func (swagger *Swagger) getHeadersByModel(model interface{}) openapi3.Headers {
+	type_ := reflect.TypeOf(model)
+	if type_ == nil {
+		return nil
+	}
+	for type_.Kind() == reflect.Ptr {
+		type_ = type_.Elem()
+	}
+	if type_.Kind() != reflect.Struct {
+		return nil
+	}
+
+	headers := make(openapi3.Headers)
+	fields, _ := structFields(type_, reflect.New(type_).Elem(), HEADER, true)
+	for _, f := range fields {
+		field := f.StructField
+		tags, err := structtag.Parse(string(field.Tag))
+		if err != nil {
+			panic(err)
+		}
+		headerTag, err := tags.Get(HEADER)
+		if err != nil || headerTag.Name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		var schema *openapi3.Schema
+		if fieldType == reflect.TypeOf(time.Time{}) {
+			// dates are sent in the HTTP date format rather than RFC 3339
+			schema = openapi3.NewStringSchema()
+		} else {
+			_, schema = swagger.getSchemaByType(fieldType, false)
+		}
+		if schema == nil {
+			schema = openapi3.NewStringSchema()
+		}
+		applyValueTags(schema, tags)
+		applyValidateTags(schema, tags)
+
+		header := &openapi3.Header{Parameter: openapi3.Parameter{Schema: openapi3.NewSchemaRef("", schema)}}
+		if descriptionTag, err := tags.Get(DESCRIPTION); err == nil {
+			header.Description = descriptionTag.Name
+		}
+		header.Required = isRequiredTags(tags)
+		header.Examples = getExamples(schema, tags)
+		headers[headerTag.Name] = &openapi3.HeaderRef{Value: header}
+	}
+	return headers
+}
+
+var pathParamRegex = regexp.MustCompile(`[:*]([^/]+)`)
+
+// fixPath convert gin path params to OpenAPI templates, e.g. /:user_id -> /{user_id}, /*filepath -> /{filepath}
 func (swagger *Swagger) fixPath(path string) string {
-	reg := regexp.MustCompile("/:([0-9a-zA-Z]+)")
-	return reg.ReplaceAllString(path, "/{${1}}")
+	return pathParamRegex.ReplaceAllString(path, "{${1}}")
+}
+
+// checkPathParameters match the path parameters of the model with the params of path, OpenAPI
+// requires every templated segment to be declared. Missing params are documented as strings and
+// fields without a matching segment are dropped, unless StrictPathParams is set which panics instead
+func (swagger *Swagger) checkPathParameters(
+	method, path string,
+	parameters openapi3.Parameters,
+) openapi3.Parameters {
+	params := make(map[string]bool)
+	for _, match := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		params[match[1]] = false
+	}
+
+	checked := make(openapi3.Parameters, 0, len(parameters))
+	for _, parameter := range parameters {
+		if parameter.Value.In != openapi3.ParameterInPath {
+			checked = append(checked, parameter)
+			continue
+		}
+		if _, ok := params[parameter.Value.Name]; !ok {
+			if swagger.StrictPathParams {
+				log.Panicf("%s %s: uri field '%s' has no matching path param", method, path, parameter.Value.Name)
+			}
+			continue
+		}
+		params[parameter.Value.Name] = true
+		parameter.Value.Required = true
+		checked = append(checked, parameter)
+	}
+
+	for _, match := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		if params[name] {
+			continue
+		}
+		if swagger.StrictPathParams {
+			log.Panicf("%s %s: path param '%s' has no matching uri field", method, path, name)
+		}
+		params[name] = true
+		parameter := openapi3.NewPathParameter(name).WithSchema(openapi3.NewStringSchema())
+		if strings.HasPrefix(match[0], "*") {
+			parameter.Description = "catch-all, starts with / and may contain more segments"
+		}
+		checked = append(checked, &openapi3.ParameterRef{Value: parameter})
+	}
+	return checked
 }
 
 func (swagger *Swagger) getPaths() *openapi3.Paths {
 	paths := openapi3.NewPaths()
-	for group, routers := range swagger.Routers {
-		for path, m := range routers {
+	// walk the routers in a stable order, so the document is the same on every build
+	groups := make([]*gin.RouterGroup, 0, len(swagger.Routers))
+	for group := range swagger.Routers {
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].BasePath() < groups[j].BasePath()
+	})
+	for _, group := range groups {
+		routers := swagger.Routers[group]
+		for _, path := range sortedKeys(routers) {
+			m := routers[path]
 			path, err := url.JoinPath(group.BasePath(), path)
 			if err != nil {
 				log.Panicln(err)
 			}
 
 			pathItem := &openapi3.PathItem{}
-			for method, r := range m {
+			for _, method := range sortedKeys(m) {
+				r := m[method]
 				// r -> router
 				// handle request here
 				if r.Exclude {
 					continue
 				}
 
-				swagger.getComponentByModel(r.Model, true)
-				for _, resp := range r.Response {
-					swagger.getComponentByModel(resp.Model, false)
-				}
+				reqType := reflect.TypeOf(r.Model)
+				hasBody := reqType != nil && r.HasBody(method) && hasBodyFields(reqType)
 
 				model := r.Model
 				operation := &openapi3.Operation{
@@ -673,17 +955,17 @@ func (swagger *Swagger) getPaths() *openapi3.Paths {
 					Summary:     r.Summary,
 					Description: r.Description,
 					Deprecated:  r.Deprecated,
-					Responses:   swagger.getResponsesRef(r.Response, r.ResponseContentType),
-					Parameters:  swagger.getParametersByModel(model),
-					Security:    swagger.getSecurityRequirements(r.Securities),
+					Responses:   swagger.getResponsesRef(r.Response, r.GetResponseContentTypes()),
+					Parameters:  swagger.checkPathParameters(method, path, swagger.getParametersByModel(model)),
+					Security:    swagger.getSecurityRequirements(r.Securities, r.Scopes),
 				}
 
-				var requestBody *openapi3.RequestBodyRef
-				reqType := reflect.TypeOf(r.Model)
-				if reqType != nil {
-					requestBody = swagger.getRequestBodyRef(
-						reflect.TypeOf(r.Model).Name(),
-						r.RequestContentType,
+				swagger.addProblemResponses(operation.Responses, r)
+
+				if hasBody {
+					operation.RequestBody = swagger.getRequestBodyRef(
+						reqType,
+						r.GetRequestContentTypes(),
 					)
 				}
 
@@ -691,12 +973,10 @@ func (swagger *Swagger) getPaths() *openapi3.Paths {
 					pathItem.Get = operation
 				} else if method == http.MethodPost {
 					pathItem.Post = operation
-					operation.RequestBody = requestBody
 				} else if method == http.MethodDelete {
 					pathItem.Delete = operation
 				} else if method == http.MethodPut {
 					pathItem.Put = operation
-					operation.RequestBody = requestBody
 				} else if method == http.MethodPatch {
 					pathItem.Patch = operation
 				} else if method == http.MethodHead {
@@ -717,6 +997,9 @@ func (swagger *Swagger) getPaths() *openapi3.Paths {
 }
 
 func (swagger *Swagger) BuildOpenAPI() {
+	swagger.schemaTypes = make(map[schemaKey]string)
+	swagger.typeNames = make(map[reflect.Type]string)
+	swagger.schemaNames = make(map[string]reflect.Type)
 	components := openapi3.NewComponents()
 	components.SecuritySchemes = openapi3.SecuritySchemes{}
 	swagger.OpenAPI = &openapi3.T{
@@ -733,6 +1016,7 @@ func (swagger *Swagger) BuildOpenAPI() {
 		Components: &components,
 	}
 	swagger.OpenAPI.Paths = swagger.getPaths()
+	swagger.nameComponents()
 }
 
 func (swagger *Swagger) MarshalJSON() ([]byte, error) {
@@ -811,9 +1095,35 @@ func (swagger *Swagger) WithRedocOptions(options map[string]interface{}) *Swagge
 	return swagger
 }
 
-func (swagger *Swagger) checkSchemaExist(name string) bool {
-	for _, schema := range swagger.OpenAPI.Components.Schemas {
-		if schema.Value != nil && schema.Value.Title == name {
+func (swagger *Swagger) WithEmbeddedAllOf() *Swagger {
+	EmbeddedAllOf()(swagger)
+	return swagger
+}
+
+func (swagger *Swagger) WithSchemaNaming(naming NamingStrategy) *Swagger {
+	SchemaNaming(naming)(swagger)
+	return swagger
+}
+
+func (swagger *Swagger) WithStrictSchemaNames() *Swagger {
+	StrictSchemaNames()(swagger)
+	return swagger
+}
+
+func (swagger *Swagger) WithStrictPathParams() *Swagger {
+	StrictPathParams()(swagger)
+	return swagger
+}
+
+func (swagger *Swagger) WithTypeSchema(type_ reflect.Type, schema *openapi3.Schema) *Swagger {
+	TypeSchema(type_, schema)(swagger)
+	return swagger
+}
+
+// checkSchemaExist report whether a request or response component of type_ is built or being built
+func (swagger *Swagger) checkSchemaExist(type_ reflect.Type) bool {
+	for key := range swagger.schemaTypes {
+		if key.type_ == type_ {
 			return true
 		}
 	}
@@ -821,19 +1131,17 @@ func (swagger *Swagger) checkSchemaExist(name string) bool {
 	return false
 }
 
-func generateRefName(structName string) string {
-	return "#/components/schemas/" + structName
-}
-
-func removePackageName(name string) string {
-	if !strings.HasSuffix(name, "]") {
-		split := strings.Split(name, ".")
-		return split[len(split)-1]
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	structSplit := strings.Split(name, ".")
-	templatesplit := strings.Split(name, "[")
-	return strings.TrimSuffix(structSplit[len(structSplit)-1], "]") + templatesplit[0]
+func generateRefName(structName string) string {
+	return "#/components/schemas/" + structName
 }
 
 func isBuiltinType(t reflect.Type) bool {
@@ -849,13 +1157,9 @@ func isBuiltinType(t reflect.Type) bool {
 }
 
 func isRequiredTags(tags *structtag.Tags) bool {
-	validateTag, err := tags.Get(VALIDATE)
-	if err == nil {
-		names := strings.Split(validateTag.Name, ",")
-		for _, name := range names {
-			if name == "required" {
-				return true
-			}
+	for _, name := range validateRules(tags) {
+		if name == "required" {
+			return true
 		}
 	}
 
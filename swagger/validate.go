@@ -0,0 +1,189 @@
+package swagger
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/structtag"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+var oneOfRegex = regexp.MustCompile(`'[^']*'|\S+`)
+
+// validateRules split the validate tag into its rules, e.g. "required,min=1" -> ["required", "min=1"]
+func validateRules(tags *structtag.Tags) []string {
+	validateTag, err := tags.Get(VALIDATE)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(validateTag.Value(), ",")
+}
+
+// applyValidateTags translate the validator rules of a field into schema constraints
+func applyValidateTags(schema *openapi3.Schema, tags *structtag.Tags) {
+	if schema == nil {
+		return
+	}
+	applyValidateRules(schema, validateRules(tags))
+}
+
+func applyValidateRules(schema *openapi3.Schema, rules []string) {
+	for i, rule := range rules {
+		// or rules can't be expressed by a single schema
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// the rest of the rules apply to the slice items or map values
+			var items *openapi3.SchemaRef
+			if schema.Items != nil {
+				items = schema.Items
+			} else if schema.AdditionalProperties.Schema != nil {
+				items = schema.AdditionalProperties.Schema
+			}
+			if items != nil && items.Ref == "" && items.Value != nil {
+				applyValidateRules(items.Value, skipKeysRules(rules[i+1:]))
+			}
+			return
+		case "min", "gte":
+			setLowerBound(schema, param, false)
+		case "gt":
+			setLowerBound(schema, param, true)
+		case "max", "lte":
+			setUpperBound(schema, param, false)
+		case "lt":
+			setUpperBound(schema, param, true)
+		case "len":
+			setLowerBound(schema, param, false)
+			setUpperBound(schema, param, false)
+		case "oneof":
+			schema.Enum = nil
+			for _, val := range oneOfRegex.FindAllString(param, -1) {
+				schema.Enum = append(schema.Enum, enumValue(schema, strings.Trim(val, "'")))
+			}
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4", "uuid5":
+			schema.Format = "uuid"
+		case "ipv4", "ipv6", "hostname":
+			schema.Format = name
+		case "ip":
+			// a schema has a single format, either one of the address formats is accepted
+			schema.AnyOf = openapi3.SchemaRefs{
+				openapi3.NewSchemaRef("", &openapi3.Schema{Format: "ipv4"}),
+				openapi3.NewSchemaRef("", &openapi3.Schema{Format: "ipv6"}),
+			}
+		case "datetime":
+			setDateTimeFormat(schema, param)
+		case "alpha":
+			setPattern(schema, "^[a-zA-Z]+$")
+		case "alphanum":
+			setPattern(schema, "^[a-zA-Z0-9]+$")
+		case "numeric":
+			setPattern(schema, "^[-+]?[0-9]+(?:\\.[0-9]+)?$")
+		case "startswith":
+			setPattern(schema, "^"+regexp.QuoteMeta(param))
+		case "endswith":
+			setPattern(schema, regexp.QuoteMeta(param)+"$")
+		case "contains":
+			setPattern(schema, regexp.QuoteMeta(param))
+		case "unique":
+			schema.UniqueItems = true
+		}
+	}
+}
+
+// setPattern set the pattern of schema unless a previous rule or the type already did, a schema
+// holds a single pattern and the first one is kept rather than overwritten
+func setPattern(schema *openapi3.Schema, pattern string) {
+	if schema.Pattern == "" {
+		schema.Pattern = pattern
+	}
+}
+
+// skipKeysRules drop the keys...endkeys span following a dive, its rules apply to the map keys
+// which the schema can't describe
+func skipKeysRules(rules []string) []string {
+	if len(rules) == 0 || rules[0] != "keys" {
+		return rules
+	}
+	for i, rule := range rules {
+		if rule == "endkeys" {
+			return rules[i+1:]
+		}
+	}
+	return nil
+}
+
+func setLowerBound(schema *openapi3.Schema, param string, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		schema.Min = &n
+		schema.ExclusiveMin = exclusive
+	case exclusive:
+		setLowerBound(schema, strconv.FormatFloat(n+1, 'f', -1, 64), false)
+	case schema.Type.Is(openapi3.TypeString):
+		schema.MinLength = uint64(n)
+	case schema.Type.Is(openapi3.TypeArray):
+		schema.MinItems = uint64(n)
+	case schema.Type.Is(openapi3.TypeObject):
+		schema.MinProps = uint64(n)
+	}
+}
+
+func setUpperBound(schema *openapi3.Schema, param string, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	u := uint64(n)
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		schema.Max = &n
+		schema.ExclusiveMax = exclusive
+	case exclusive:
+		setUpperBound(schema, strconv.FormatFloat(n-1, 'f', -1, 64), false)
+	case schema.Type.Is(openapi3.TypeString):
+		schema.MaxLength = &u
+	case schema.Type.Is(openapi3.TypeArray):
+		schema.MaxItems = &u
+	case schema.Type.Is(openapi3.TypeObject):
+		schema.MaxProps = &u
+	}
+}
+
+// setDateTimeFormat set the format matching a time layout, layouts without one, e.g. time.TimeOnly
+// which lacks the offset of the time format, are only noted in the description
+func setDateTimeFormat(schema *openapi3.Schema, layout string) {
+	switch layout {
+	case time.RFC3339, time.RFC3339Nano:
+		schema.Format = "date-time"
+	case time.DateOnly:
+		schema.Format = "date"
+	default:
+		schema.Description = strings.TrimSpace(schema.Description + " (layout: " + layout + ")")
+	}
+}
+
+func enumValue(schema *openapi3.Schema, val string) interface{} {
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger):
+		return gconv.Int64(val)
+	case schema.Type.Is(openapi3.TypeNumber):
+		return gconv.Float64(val)
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return gconv.Bool(val)
+	}
+	return val
+}
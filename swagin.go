@@ -209,6 +209,9 @@ func (g *SwaGin) initRouters() {
 		for path, m := range routers {
 			path = g.fullPath(path)
 			for method, r := range m {
+				if r.ErrorHandler == nil {
+					router.ErrorHandler(g.ErrorHandler)(r)
+				}
 				handlers := r.GetHandlers()
 				if method == http.MethodGet {
 					group.GET(path, handlers...)
@@ -0,0 +1,5 @@
+package order
+
+type Response struct {
+	OrderID string `json:"orderId"`
+}
@@ -0,0 +1,433 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sparkle-technologies/swagger_gin"
+	"github.com/sparkle-technologies/swagger_gin/problem"
+	"github.com/sparkle-technologies/swagger_gin/router"
+	"github.com/sparkle-technologies/swagger_gin/security"
+)
+
+// postJSON serve a POST of a JSON body to path
+func postJSON(engine *swagger_gin.SwaGin, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", binding.MIMEJSON)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestScopedModel(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	// pooled models are no longer reachable from the context once the route is done with them
+	engine.Use(func(c *gin.Context) {
+		c.Next()
+		if req, ok := router.GetModel[TestRequest](c); ok {
+			t.Errorf("expected the released model to be unpublished, got %+v", req)
+		}
+	})
+	engine.GET("/echo", router.New(func(c *gin.Context, req TestRequest) {
+		time.Sleep(time.Millisecond)
+		c.String(http.StatusOK, req.Username)
+	}, router.ModelPool()))
+	engine.Init()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			username := fmt.Sprintf("user%d", i)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo?username="+username, nil))
+			if w.Body.String() != username {
+				t.Errorf("expected %s, got %s", username, w.Body.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string {
+	return "not found"
+}
+
+func (notFoundError) StatusCode() int {
+	return http.StatusNotFound
+}
+
+func TestNewTyped(t *testing.T) {
+	engine := swagger_gin.New(newSwagger()).WithErrorHandler(func(c *gin.Context, err error, status int) {
+		c.String(status, err.Error())
+	})
+	engine.GET("/typed", router.NewTyped(func(c *gin.Context, req TestRequest) (TestResponse, error) {
+		if req.Username == "" {
+			return TestResponse{}, notFoundError{}
+		}
+		return TestResponse{Code: 200, Message: req.Username}, nil
+	}))
+	engine.Init()
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/typed?username=foo", nil))
+	if w.Code != http.StatusOK || w.Body.String() != `{"code":200,"message":"foo"}` {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/typed", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+
+	response := engine.Swagger.OpenAPI.Paths.Find("/typed").Get.Responses.Value("200")
+	if response == nil || response.Value.Content.Get("application/json").Schema.Ref != "#/components/schemas/TestResponse" {
+		t.Errorf("expected documented 200 response")
+	}
+}
+
+type ProblemRequest struct {
+	Items []struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"items" form:"items" validate:"dive"`
+	Limit int `query:"limit" validate:"max=10"`
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.POST("/problem", router.New(func(c *gin.Context, req ProblemRequest) {
+		c.Status(http.StatusOK)
+	}, router.Security(&security.Bearer{})))
+	engine.Init()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/problem?limit=20", strings.NewReader(`{"items":[{}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	engine.ServeHTTP(w, req)
+
+	var p problem.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != problem.MIMEProblemJSON {
+		t.Errorf("unexpected response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if p.Status != http.StatusBadRequest || p.Instance != "/problem" || len(p.Errors) != 2 {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+	if p.Errors[0] != (problem.FieldError{Field: "items[0].name", Rule: "required"}) ||
+		p.Errors[1] != (problem.FieldError{Field: "limit", Rule: "max", Param: "10"}) {
+		t.Errorf("unexpected errors %+v", p.Errors)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/problem", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Content-Type") != problem.MIMEProblemJSON {
+		t.Errorf("unexpected response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	responses := engine.Swagger.OpenAPI.Paths.Find("/problem").Post.Responses
+	for _, code := range []string{"400", "401", "500"} {
+		response := responses.Value(code)
+		if response == nil || response.Value.Content.Get(problem.MIMEProblemJSON).Schema.Ref != "#/components/schemas/Problem" {
+			t.Errorf("expected documented %s problem response", code)
+		}
+	}
+}
+
+type BodyRequest struct {
+	Username string `json:"username" form:"username"`
+}
+
+func TestRequestBodyMethods(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	handler := func(c *gin.Context, req BodyRequest) {
+		c.String(http.StatusOK, req.Username)
+	}
+	engine.PATCH("/body", router.New(handler))
+	engine.DELETE("/body", router.New(handler, router.RequestBody(false)))
+	engine.GET("/body", router.New(handler, router.RequestBody(true)))
+	engine.Init()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/body", strings.NewReader(`{"username":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "foo" {
+		t.Errorf("expected PATCH body to be bound, got %s", w.Body.String())
+	}
+
+	pathItem := engine.Swagger.OpenAPI.Paths.Find("/body")
+	if pathItem.Patch.RequestBody == nil || pathItem.Get.RequestBody == nil || pathItem.Delete.RequestBody != nil {
+		t.Errorf("unexpected documented request bodies")
+	}
+}
+
+func TestRequestContentTypes(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.POST("/content", router.New(func(c *gin.Context, req BodyRequest) {
+		c.String(http.StatusOK, req.Username)
+	}, router.RequestContentTypes(binding.MIMEJSON, binding.MIMEMSGPACK)))
+	engine.Init()
+
+	for contentType, expected := range map[string]int{
+		"application/json; charset=utf-8": http.StatusOK,
+		"Application/JSON":                http.StatusOK,
+		"text/plain":                      http.StatusUnsupportedMediaType,
+		"application/xml":                 http.StatusUnsupportedMediaType,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/content", strings.NewReader(`{"username":"foo"}`))
+		req.Header.Set("Content-Type", contentType)
+		engine.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("%s: expected %d, got %d", contentType, expected, w.Code)
+		}
+	}
+
+	content := engine.Swagger.OpenAPI.Paths.Find("/content").Post.RequestBody.Value.Content
+	if content.Get(binding.MIMEJSON) == nil || content.Get(binding.MIMEMSGPACK) == nil {
+		t.Errorf("expected all request content types to be documented")
+	}
+}
+
+func TestDefaultRequestContentTypes(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.POST("/any", router.New(func(c *gin.Context, req BodyRequest) {
+		c.String(http.StatusOK, req.Username)
+	}))
+	legacy := router.New(func(c *gin.Context, req BodyRequest) {
+		c.String(http.StatusOK, req.Username)
+	})
+	legacy.RequestContentType = binding.MIMEPOSTForm
+	engine.POST("/legacy", legacy)
+	engine.Init()
+
+	for _, test := range []struct {
+		path, contentType, body string
+		status                  int
+	}{
+		{"/any", binding.MIMEJSON, `{"username":"foo"}`, http.StatusOK},
+		{"/any", binding.MIMEPOSTForm, "username=foo", http.StatusOK},
+		{"/legacy", binding.MIMEPOSTForm, "username=foo", http.StatusOK},
+		{"/legacy", binding.MIMEJSON, `{"username":"foo"}`, http.StatusUnsupportedMediaType},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		engine.ServeHTTP(w, req)
+		if w.Code != test.status || (test.status == http.StatusOK && w.Body.String() != "foo") {
+			t.Errorf("%s %s: unexpected response %d %s", test.path, test.contentType, w.Code, w.Body.String())
+		}
+	}
+
+	if content := engine.Swagger.OpenAPI.Paths.Find("/legacy").Post.RequestBody.Value.Content; content.Get(binding.MIMEPOSTForm) == nil {
+		t.Errorf("expected the deprecated content type to be documented")
+	}
+}
+
+func TestResponseNegotiation(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.GET("/negotiate", router.NewTyped(func(c *gin.Context, req TestRequest) (TestResponse, error) {
+		return TestResponse{Code: 200, Message: "ok"}, nil
+	}, router.ResponseContentTypes(binding.MIMEJSON, binding.MIMEXML, binding.MIMEYAML)))
+	legacy := router.NewTyped(func(c *gin.Context, req TestRequest) (TestResponse, error) {
+		return TestResponse{Code: 200, Message: "ok"}, nil
+	})
+	legacy.ResponseContentType = binding.MIMEXML
+	engine.GET("/legacy", legacy)
+	engine.GET("/protobuf", router.NewTyped(func(c *gin.Context, req TestRequest) (TestResponse, error) {
+		return TestResponse{Code: 200, Message: "ok"}, nil
+	}, router.ResponseContentTypes(binding.MIMEPROTOBUF, binding.MIMEJSON)))
+	engine.Init()
+
+	for accept, expected := range map[string]string{
+		"":                                     binding.MIMEJSON,
+		"*/*":                                  binding.MIMEJSON,
+		"application/xml":                      binding.MIMEXML,
+		"application/xml;q=0.5, application/*": binding.MIMEJSON,
+		"text/csv, application/x-yaml;q=0.1":   binding.MIMEYAML,
+		"text/csv":                             problem.MIMEProblemJSON,
+		"application/json;q=0, */*":            binding.MIMEXML,
+		"*/*, application/yaml;q=0, application/x-yaml;q=1": binding.MIMEYAML,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/negotiate", nil)
+		req.Header.Set("Accept", accept)
+		engine.ServeHTTP(w, req)
+		if w.Header().Get("Content-Type") != expected {
+			t.Errorf("%s: expected %s, got %s", accept, expected, w.Header().Get("Content-Type"))
+		}
+	}
+
+	// values that aren't proto messages can't be rendered as protobuf
+	for accept, status := range map[string]int{
+		binding.MIMEPROTOBUF:                              http.StatusNotAcceptable,
+		binding.MIMEPROTOBUF + ", application/json;q=0.5": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/protobuf", nil)
+		req.Header.Set("Accept", accept)
+		engine.ServeHTTP(w, req)
+		if w.Code != status || (status == http.StatusOK && w.Header().Get("Content-Type") != binding.MIMEJSON) {
+			t.Errorf("%s: unexpected response %d %s", accept, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/legacy", nil))
+	if w.Header().Get("Content-Type") != binding.MIMEXML {
+		t.Errorf("expected the deprecated response content type, got %s", w.Header().Get("Content-Type"))
+	}
+
+	responses := engine.Swagger.OpenAPI.Paths.Find("/negotiate").Get.Responses
+	if content := responses.Value("200").Value.Content; len(content) != 3 {
+		t.Errorf("expected all response content types to be documented, got %d", len(content))
+	}
+	if responses.Value("406") == nil {
+		t.Errorf("expected 406 to be documented")
+	}
+	if content := engine.Swagger.OpenAPI.Paths.Find("/legacy").Get.Responses.Value("200").Value.Content; content.Get(binding.MIMEXML) == nil {
+		t.Errorf("expected the deprecated response content type to be documented")
+	}
+}
+
+func TestPolymorphicBinding(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.POST("/payments", router.New(func(c *gin.Context, req PaymentRequest) {
+		kinds := []string{req.Method.Kind()}
+		for _, fallback := range req.Fallback {
+			kinds = append(kinds, fmt.Sprintf("%T", fallback))
+		}
+		var labels []string
+		for label, method := range req.ByLabel {
+			labels = append(labels, fmt.Sprintf("%s=%T", label, method))
+		}
+		sort.Strings(labels)
+		kinds = append(kinds, labels...)
+		c.String(http.StatusOK, fmt.Sprintf("%T %s", req.Method, strings.Join(kinds, " ")))
+	}))
+	engine.Init()
+
+	body := `{"amount":10,"method":{"type":"card","number":"4242"},"fallback":[{"type":"iban","iban":"DE00"},{"type":"card","number":"1"}]}`
+	w := postJSON(engine, "/payments", body)
+	if w.Code != http.StatusOK || w.Body.String() != "test.Card card *test.Iban test.Card" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	body = `{"method":{"type":"card","number":"4242"},"byLabel":{"work":{"type":"iban","iban":"DE00"},"home":{"type":"card","number":"1"}}}`
+	w = postJSON(engine, "/payments", body)
+	if w.Code != http.StatusOK || w.Body.String() != "test.Card card home=test.Card work=*test.Iban" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = postJSON(engine, "/payments", `{"method":{"type":"card","number":"4242"},"byLabel":{"home":{"type":"cash"}}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown discriminator in a map, got %d", w.Code)
+	}
+
+	w = postJSON(engine, "/payments", `{"method":{"type":"cash"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown discriminator, got %d", w.Code)
+	}
+
+	w = postJSON(engine, "/payments", `{"method":{"type":"card"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected the implementation to be validated, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReadOnlyFields(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	handler := func(c *gin.Context, req Account) {
+		c.String(http.StatusOK, req.ID+"|"+req.Email)
+	}
+	engine.POST("/bind", router.New(handler))
+	engine.POST("/strip", router.New(handler, router.ReadOnly(router.ReadOnlyStrip)))
+	engine.POST("/reject", router.New(handler, router.ReadOnly(router.ReadOnlyReject)))
+	engine.Init()
+
+	body := `{"id":"forged","email":"a@b.c"}`
+	if w := postJSON(engine, "/bind", body); w.Body.String() != "forged|a@b.c" {
+		t.Errorf("expected readOnly fields to be bound by default, got %s", w.Body.String())
+	}
+	if w := postJSON(engine, "/strip", body); w.Body.String() != "|a@b.c" {
+		t.Errorf("expected readOnly fields to be stripped, got %s", w.Body.String())
+	}
+	w := postJSON(engine, "/reject", body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "id") {
+		t.Errorf("expected readOnly fields to be rejected, got %d %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(engine, "/reject", `{"email":"a@b.c"}`); w.Code != http.StatusOK {
+		t.Errorf("expected requests without readOnly fields to pass, got %d", w.Code)
+	}
+}
+
+func TestSliceModel(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.POST("/batch", router.New(func(c *gin.Context, req []BodyRequest) {
+		c.String(http.StatusOK, fmt.Sprint(len(req)))
+	}))
+	engine.POST("/validated", router.New(func(c *gin.Context, req []ValidateRequest) {
+		c.Status(http.StatusOK)
+	}))
+	engine.Init()
+
+	if w := postJSON(engine, "/batch", `[{"username":"a"},{"username":"b"}]`); w.Code != http.StatusOK || w.Body.String() != "2" {
+		t.Errorf("expected the slice to be bound, got %d %s", w.Code, w.Body.String())
+	}
+	w := postJSON(engine, "/validated", `[{}]`)
+	var p problem.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || w.Code != http.StatusBadRequest || len(p.Errors) == 0 {
+		t.Fatalf("expected the items to be validated, got %d %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(p.Errors[0].Field, "[0].") {
+		t.Errorf("expected item paths, got %s", p.Errors[0].Field)
+	}
+}
+
+func TestWriteHeaders(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	engine.GET("/limited", router.New(func(c *gin.Context, req TestRequest) {
+		router.WriteHeaders(c, &RateLimitHeaders{
+			Remaining: 0,
+			Links:     []string{"</a>; rel=next", "</b>; rel=last"},
+			Expires:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		})
+		c.Status(http.StatusOK)
+	}))
+	engine.Init()
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	header := w.Header()
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("expected zero counts to be written, got %q", header.Get("X-RateLimit-Remaining"))
+	}
+	if _, ok := header["Etag"]; ok {
+		t.Errorf("expected empty omitempty headers to be left out")
+	}
+	if _, ok := header["Location"]; ok {
+		t.Errorf("expected nil pointers to be left out")
+	}
+	if header.Get("Expires") != "Tue, 02 Jan 2024 03:04:05 GMT" {
+		t.Errorf("expected an HTTP date, got %q", header.Get("Expires"))
+	}
+	if len(header.Values("Link")) != 2 {
+		t.Errorf("expected repeated Link headers, got %v", header.Values("Link"))
+	}
+}
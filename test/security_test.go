@@ -0,0 +1,777 @@
+package test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/sparkle-technologies/swagger_gin"
+	"github.com/sparkle-technologies/swagger_gin/router"
+	"github.com/sparkle-technologies/swagger_gin/security"
+)
+
+// signToken build a JWT signed with key, the test counterpart of security.JWTVerifier
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	input := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	var err error
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg == "PS256" {
+			signature, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		if err == nil {
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(input))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	now := time.Unix(1700000000, 0)
+	verifier := &security.JWTVerifier{
+		Keys: security.StaticKeys{
+			"hs": secret,
+			"rs": &rsaKey.PublicKey,
+			"es": &ecKey.PublicKey,
+			"ed": edPublic,
+		},
+		Issuer:    "https://issuer.example",
+		Audience:  "api",
+		ClockSkew: time.Minute,
+		Now:       func() time.Time { return now },
+	}
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": "https://issuer.example",
+			"aud": []string{"api", "other"},
+			"sub": "user",
+			"exp": now.Add(time.Hour).Unix(),
+			"nbf": now.Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	for _, test := range []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"HS256", signToken(t, "HS256", "hs", secret, claims(nil)), nil},
+		{"RS256", signToken(t, "RS256", "rs", rsaKey, claims(nil)), nil},
+		{"PS256", signToken(t, "PS256", "rs", rsaKey, claims(nil)), nil},
+		{"ES256", signToken(t, "ES256", "es", ecKey, claims(nil)), nil},
+		{"EdDSA", signToken(t, "EdDSA", "ed", edKey, claims(nil)), nil},
+		{"string audience", signToken(t, "HS256", "hs", secret, claims(map[string]interface{}{"aud": "api"})), nil},
+		{"expired within skew", signToken(t, "HS256", "hs", secret, claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})), nil},
+		{"expired", signToken(t, "HS256", "hs", secret, claims(map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()})), security.ErrTokenExpired},
+		{"not valid yet", signToken(t, "HS256", "hs", secret, claims(map[string]interface{}{"nbf": now.Add(2 * time.Minute).Unix()})), security.ErrTokenNotValidYet},
+		{"issuer", signToken(t, "HS256", "hs", secret, claims(map[string]interface{}{"iss": "https://other.example"})), security.ErrTokenIssuer},
+		{"audience", signToken(t, "HS256", "hs", secret, claims(map[string]interface{}{"aud": "other"})), security.ErrTokenAudience},
+		{"wrong key", signToken(t, "HS256", "hs", []byte("guess"), claims(nil)), security.ErrTokenSignature},
+		{"key type confusion", signToken(t, "HS256", "rs", secret, claims(nil)), security.ErrTokenSignature},
+		{"unknown kid", signToken(t, "HS256", "missing", secret, claims(nil)), security.ErrUnknownSigningKey},
+		{"none", signToken(t, "none", "hs", secret, claims(nil)), security.ErrTokenAlgorithm},
+		{"malformed", "not.a-token", security.ErrTokenMalformed},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := verifier.Verify(context.Background(), test.token)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+			if err == nil && (c.Subject != "user" || !c.Audience.Contains("api")) {
+				t.Errorf("unexpected claims %+v", c)
+			}
+		})
+	}
+
+	// custom algorithms too short to name a hash are rejected rather than panic
+	verifier.Algorithms = []string{"X"}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "X", "hs", secret, claims(nil))); !errors.Is(err, security.ErrTokenAlgorithm) {
+		t.Errorf("expected %v, got %v", security.ErrTokenAlgorithm, err)
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	var mu sync.Mutex
+	keys := []map[string]string{rsaJWK("old", &oldKey.PublicKey)}
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer server.Close()
+
+	verifier := &security.JWTVerifier{Keys: &security.JWKS{URL: server.URL, MinRefreshInterval: time.Nanosecond}}
+	claims := map[string]interface{}{"sub": "user"}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "RS256", "old", oldKey, claims)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "RS256", "old", oldKey, claims)); err != nil || fetches != 1 {
+		t.Fatalf("expected cached keys, got %v after %d fetches", err, fetches)
+	}
+
+	mu.Lock()
+	keys = []map[string]string{rsaJWK("new", &newKey.PublicKey)}
+	mu.Unlock()
+	if _, err := verifier.Verify(context.Background(), signToken(t, "RS256", "new", newKey, claims)); err != nil || fetches != 2 {
+		t.Fatalf("expected rotated key, got %v after %d fetches", err, fetches)
+	}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "RS256", "old", oldKey, claims)); !errors.Is(err, security.ErrUnknownSigningKey) {
+		t.Errorf("expected retired key to be rejected, got %v", err)
+	}
+
+	// remote documents can't provide symmetric keys
+	secret := []byte("secret")
+	mu.Lock()
+	keys = []map[string]string{{"kty": "oct", "kid": "hmac", "k": base64.RawURLEncoding.EncodeToString(secret)}}
+	mu.Unlock()
+	if _, err := verifier.Verify(context.Background(), signToken(t, "HS256", "hmac", secret, claims)); err == nil {
+		t.Errorf("expected the remote symmetric key to be rejected")
+	}
+
+	// documents can also be read from disk
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	document, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "OKP", "crv": "Ed25519", "alg": "EdDSA", "x": base64.RawURLEncoding.EncodeToString(edPublic),
+	}}})
+	if err := os.WriteFile(path, document, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	verifier = &security.JWTVerifier{Keys: &security.JWKS{URL: "file://" + path}}
+	if _, err := verifier.Verify(context.Background(), signToken(t, "EdDSA", "", edKey, claims)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJWKSLoading(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var mu sync.Mutex
+	fetches, failing := 0, true
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		fail := failing
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{rsaJWK("key", &key.PublicKey)}})
+	}))
+	defer server.Close()
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return fetches
+	}
+
+	// failed loads are retried at most every MinRefreshInterval
+	keys := &security.JWKS{URL: server.URL, MinRefreshInterval: time.Hour}
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(context.Background(), "key", "RS256"); err == nil {
+			t.Fatal("expected the load to fail")
+		}
+	}
+	if count() != 1 {
+		t.Fatalf("expected a single fetch, got %d", count())
+	}
+
+	// concurrent requests share one load
+	mu.Lock()
+	failing, fetches = false, 0
+	mu.Unlock()
+	keys = &security.JWKS{URL: server.URL}
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := keys.Key(context.Background(), "key", "RS256")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count() != 1 {
+		t.Errorf("expected a single fetch, got %d", count())
+	}
+}
+
+type TenantClaims struct {
+	Subject string `json:"sub"`
+	Tenant  string `json:"tenant"`
+}
+
+func TestBearerVerifier(t *testing.T) {
+	secret := []byte("secret")
+	bearer := &security.Bearer{Verifier: &security.JWTVerifier{Keys: security.StaticKeys{"": secret}}}
+	engine := swagger_gin.New(newSwagger())
+	engine.GET("/me", router.NewX(func(c *gin.Context) {
+		claims, _ := security.GetClaims(c)
+		tenant, err := security.DecodeClaims[TenantClaims](c)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, claims.Subject+"@"+tenant.Tenant)
+	}, router.Security(bearer)))
+	engine.Init()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", "", secret, map[string]interface{}{"sub": "user", "tenant": "acme"}))
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "user@acme" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", "", []byte("guess"), map[string]interface{}{"sub": "user"}))
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+// newIssuer start a stand-in OpenID issuer serving its discovery document, its JWKS and an
+// introspection endpoint accepting the token "opaque"
+func newIssuer(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   server.URL,
+			"jwks_uri": server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{rsaJWK("issuer", &key.PublicKey)}})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "api" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PostFormValue("token") != "opaque" {
+			_ = json.NewEncoder(w).Encode(map[string]bool{"active": false})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"active": true, "sub": "user", "scope": "read", "client_id": "app"})
+	})
+	return server
+}
+
+func TestOpenIDAuthorize(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	issuer := newIssuer(t, key)
+	engine := swagger_gin.New(newSwagger())
+	engine.GET("/me", router.NewX(func(c *gin.Context) {
+		claims, _ := security.GetClaims(c)
+		c.String(http.StatusOK, claims.Subject)
+	}, router.Security(&security.OpenID{ConnectUrl: issuer.URL + "/.well-known/openid-configuration", Audience: "api"})))
+	engine.GET("/unconfigured", router.NewX(func(c *gin.Context) {
+		c.String(http.StatusOK, "user")
+	}, router.Security(&security.OpenID{ConnectUrl: issuer.URL + "/.well-known/openid-configuration"})))
+	engine.GET("/any", router.NewX(func(c *gin.Context) {
+		c.String(http.StatusOK, "user")
+	}, router.Security(&security.OpenID{ConnectUrl: issuer.URL + "/.well-known/openid-configuration", AnyAudience: true})))
+
+	// a discovery document can't make the server read its disk
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	document, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{rsaJWK("issuer", &key.PublicKey)}})
+	if err := os.WriteFile(path, document, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.URL, "jwks_uri": "file://" + path})
+	}))
+	defer local.Close()
+	engine.GET("/local", router.NewX(func(c *gin.Context) {
+		c.String(http.StatusOK, "user")
+	}, router.Security(&security.OpenID{ConnectUrl: local.URL, Audience: "api"})))
+	engine.Init()
+
+	exp := time.Now().Add(time.Hour).Unix()
+	other := signToken(t, "RS256", "issuer", key, map[string]interface{}{"iss": issuer.URL, "aud": "other", "exp": exp})
+	for _, test := range []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"valid", "/me", signToken(t, "RS256", "issuer", key, map[string]interface{}{"iss": issuer.URL, "aud": "api", "sub": "user", "exp": exp}), http.StatusOK},
+		{"other issuer", "/me", signToken(t, "RS256", "issuer", key, map[string]interface{}{"iss": "https://other.example", "aud": "api", "exp": exp}), http.StatusUnauthorized},
+		{"other audience", "/me", other, http.StatusUnauthorized},
+		{"missing", "/me", "", http.StatusUnauthorized},
+		{"no audience configured", "/unconfigured", other, http.StatusUnauthorized},
+		{"any audience", "/any", other, http.StatusOK},
+		{"discovered file", "/local", signToken(t, "RS256", "issuer", key, map[string]interface{}{"iss": issuer.URL, "aud": "api", "sub": "user", "exp": exp}), http.StatusUnauthorized},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			engine.ServeHTTP(w, req)
+			if w.Code != test.status || (test.status == http.StatusOK && w.Body.String() != "user") {
+				t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOpenIDDiscovery(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	issuer := newIssuer(t, key)
+	var mu sync.Mutex
+	fetches, failing := 0, true
+	release := make(chan struct{})
+	discovery := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		fail := failing
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.URL, "jwks_uri": issuer.URL + "/jwks"})
+	}))
+	defer discovery.Close()
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return fetches
+	}
+	token := signToken(t, "RS256", "issuer", key, map[string]interface{}{"iss": issuer.URL, "aud": "api", "sub": "user", "exp": time.Now().Add(time.Hour).Unix()})
+	newEngine := func(openID *security.OpenID) *swagger_gin.SwaGin {
+		engine := swagger_gin.New(newSwagger())
+		engine.GET("/me", router.NewX(func(c *gin.Context) {
+			claims, _ := security.GetClaims(c)
+			c.String(http.StatusOK, claims.Subject)
+		}, router.Security(openID)))
+		engine.Init()
+		return engine
+	}
+	serve := func(engine *swagger_gin.SwaGin) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// failed discoveries are retried at most every RetryInterval
+	engine := newEngine(&security.OpenID{ConnectUrl: discovery.URL, Audience: "api", RetryInterval: time.Hour})
+	for i := 0; i < 3; i++ {
+		if code := serve(engine); code == http.StatusOK {
+			t.Fatal("expected the discovery to fail")
+		}
+	}
+	if count() != 1 {
+		t.Fatalf("expected a single fetch, got %d", count())
+	}
+
+	// concurrent requests share one discovery
+	mu.Lock()
+	failing, fetches = false, 0
+	mu.Unlock()
+	engine = newEngine(&security.OpenID{ConnectUrl: discovery.URL, Audience: "api"})
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- serve(engine)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+	if count() != 1 {
+		t.Errorf("expected a single fetch, got %d", count())
+	}
+}
+
+func TestOAuth2Authorize(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	issuer := newIssuer(t, key)
+	engine := swagger_gin.New(newSwagger())
+	handler := func(c *gin.Context) {
+		claims, _ := security.GetClaims(c)
+		c.String(http.StatusOK, claims.Subject+" "+claims.Scope)
+	}
+	engine.GET("/introspected", router.NewX(handler, router.Security(&security.OAuth2{
+		Verifier: &security.Introspection{URL: issuer.URL + "/introspect", ClientID: "api", ClientSecret: "secret"},
+	})))
+	engine.GET("/jwt", router.NewX(handler, router.Security(&security.OAuth2{
+		Verifier: &security.JWTVerifier{Keys: &security.JWKS{URL: issuer.URL + "/jwks"}, Issuer: issuer.URL},
+	})))
+	engine.GET("/unverified", router.NewX(handler, router.Security(&security.OAuth2{})))
+	engine.Init()
+
+	for _, test := range []struct {
+		path   string
+		token  string
+		status int
+	}{
+		{"/introspected", "opaque", http.StatusOK},
+		{"/introspected", "revoked", http.StatusUnauthorized},
+		{"/jwt", signToken(t, "RS256", "issuer", key, map[string]interface{}{"iss": issuer.URL, "sub": "user", "scope": "read"}), http.StatusOK},
+		{"/jwt", "opaque", http.StatusUnauthorized},
+		{"/unverified", "opaque", http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		engine.ServeHTTP(w, req)
+		if w.Code != test.status || (test.status == http.StatusOK && w.Body.String() != "user read") {
+			t.Errorf("%s %s: unexpected response %d %s", test.path, test.token, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	secret := []byte("secret")
+	oauth2 := &security.OAuth2{
+		TokenURL: "https://issuer.example/token",
+		Scopes:   map[string]string{"orders:read": "Read orders", "orders:write": "Write orders"},
+		Verifier: &security.JWTVerifier{Keys: security.StaticKeys{"": secret}},
+	}
+	engine := swagger_gin.New(newSwagger())
+	engine.POST("/orders", router.NewX(func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	}, router.Security(oauth2, &security.Bearer{}), router.Scopes("orders:read", "orders:write")))
+	engine.Init()
+
+	for _, test := range []struct {
+		scope  string
+		status int
+	}{
+		{"orders:read orders:write profile", http.StatusCreated},
+		{"orders:read", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", "", secret, map[string]interface{}{"scope": test.scope}))
+		engine.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("scope %q: expected %d, got %d", test.scope, test.status, w.Code)
+		}
+		if test.status == http.StatusForbidden &&
+			w.Header().Get("WWW-Authenticate") != `Bearer error="insufficient_scope", scope="orders:read orders:write"` {
+			t.Errorf("unexpected challenge %s", w.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	// the scp claim grants scopes too, as an array or a space separated string
+	for _, scp := range []interface{}{[]string{"orders:read", "orders:write"}, "orders:read orders:write"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, "HS256", "", secret, map[string]interface{}{"scp": scp}))
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Errorf("scp %v: expected %d, got %d", scp, http.StatusCreated, w.Code)
+		}
+	}
+
+	operation := engine.Swagger.OpenAPI.Paths.Find("/orders").Post
+	requirements := *operation.Security
+	if len(requirements) != 1 {
+		t.Fatalf("unexpected security requirements %+v", requirements)
+	}
+	if scopes := requirements[0][security.OAuth2Auth]; len(scopes) != 2 || scopes[0] != "orders:read" || scopes[1] != "orders:write" {
+		t.Errorf("unexpected oauth2 scopes %v", scopes)
+	}
+	if scopes, ok := requirements[0][security.BearerAuth]; !ok || len(scopes) != 0 {
+		t.Errorf("unexpected bearer scopes %v", scopes)
+	}
+	if operation.Responses.Value("403") == nil {
+		t.Error("missing 403 response")
+	}
+
+	// routes whose scopes could go unchecked are rejected
+	for name, securities := range map[string][]security.ISecurity{
+		"none":       nil,
+		"api key":    {&security.ApiKey{}},
+		"unverified": {&security.Bearer{}},
+		"any of":     {security.AnyOf(oauth2, &security.Basic{})},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected unchecked scopes to panic", name)
+				}
+			}()
+			engine := swagger_gin.New(newSwagger())
+			engine.GET("/orders", router.NewX(func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			}, router.Security(securities...), router.Scopes("orders:read")))
+			engine.Init()
+		}()
+	}
+}
+
+func TestCompositeSecurity(t *testing.T) {
+	secret := []byte("secret")
+	bearer := &security.Bearer{Verifier: &security.JWTVerifier{Keys: security.StaticKeys{"": secret}}}
+	apiKey := &security.ApiKey{Name: "X-API-Key", In: "header"}
+	basic := &security.Basic{}
+	engine := swagger_gin.New(newSwagger())
+	handler := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	engine.GET("/any", router.NewX(handler, router.Security(security.AnyOf(bearer, apiKey))))
+	engine.GET("/all", router.NewX(handler, router.Security(security.AllOf(bearer, apiKey))))
+	engine.GET("/nested", router.NewX(handler, router.Security(security.AnyOf(security.AllOf(bearer, apiKey), basic))))
+	engine.GET("/claims", router.NewX(func(c *gin.Context) {
+		claims, _ := security.GetClaims(c)
+		key := c.MustGet(security.ProviderCredentials).(map[string]interface{})[security.ApiKeyAuth]
+		c.String(http.StatusOK, claims.Subject+" "+key.(string))
+	}, router.Security(security.AllOf(bearer, apiKey))))
+	engine.Init()
+
+	token := "Bearer " + signToken(t, "HS256", "", secret, map[string]interface{}{"sub": "user"})
+	for _, test := range []struct {
+		path          string
+		authorization string
+		apiKey        string
+		status        int
+	}{
+		{"/any", token, "", http.StatusOK},
+		{"/any", "", "key", http.StatusOK},
+		{"/any", "Bearer forged", "key", http.StatusOK},
+		{"/any", "", "", http.StatusUnauthorized},
+		{"/all", token, "key", http.StatusOK},
+		{"/all", token, "", http.StatusUnauthorized},
+		{"/all", "", "key", http.StatusUnauthorized},
+		{"/nested", token, "key", http.StatusOK},
+		{"/nested", "Basic dXNlcjpwYXNz", "", http.StatusOK},
+		{"/nested", token, "", http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		if test.apiKey != "" {
+			req.Header.Set("X-API-Key", test.apiKey)
+		}
+		engine.ServeHTTP(w, req)
+		if w.Code != test.status {
+			t.Errorf("%s %q %q: expected %d, got %d %s", test.path, test.authorization, test.apiKey, test.status, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/claims", nil)
+	req.Header.Set("Authorization", token)
+	req.Header.Set("X-API-Key", "key")
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "user key" {
+		t.Errorf("unexpected credentials %s", w.Body.String())
+	}
+
+	requirements := func(path string) []openapi3.SecurityRequirement {
+		return *engine.Swagger.OpenAPI.Paths.Find(path).Get.Security
+	}
+	if r := requirements("/any"); len(r) != 2 || len(r[0]) != 1 || len(r[1]) != 1 ||
+		r[0][security.BearerAuth] == nil || r[1][security.ApiKeyAuth] == nil {
+		t.Errorf("unexpected AnyOf requirements %v", r)
+	}
+	if r := requirements("/all"); len(r) != 1 || len(r[0]) != 2 {
+		t.Errorf("unexpected AllOf requirements %v", r)
+	}
+	if r := requirements("/nested"); len(r) != 2 || len(r[0]) != 2 || r[1][security.BasicAuth] == nil {
+		t.Errorf("unexpected nested requirements %v", r)
+	}
+	if engine.Swagger.OpenAPI.Components.SecuritySchemes[security.BasicAuth] == nil {
+		t.Error("missing basic security scheme")
+	}
+}
+
+type Client struct {
+	Name string
+}
+
+func TestApiKeyLocations(t *testing.T) {
+	engine := swagger_gin.New(newSwagger())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, c.MustGet(security.Credentials).(string))
+	}
+	engine.GET("/header", router.NewX(handler, router.Security(&security.ApiKey{Name: "X-API-Key"})))
+	engine.GET("/query", router.NewX(handler, router.Security(&security.ApiKey{Name: "api_key", In: "query"})))
+	engine.GET("/cookie", router.NewX(handler, router.Security(&security.ApiKey{Name: "api_key", In: "cookie"})))
+	engine.Init()
+
+	for _, test := range []struct {
+		path    string
+		prepare func(req *http.Request)
+	}{
+		{"/header", func(req *http.Request) { req.Header.Set("X-API-Key", "key") }},
+		{"/query?api_key=key", func(req *http.Request) {}},
+		{"/cookie", func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "api_key", Value: "key"}) }},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		test.prepare(req)
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "key" {
+			t.Errorf("%s: unexpected response %d %s", test.path, w.Code, w.Body.String())
+		}
+	}
+
+	// the key is only read from the documented location
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/cookie?api_key=key", nil)
+	req.Header.Set("X-API-Key", "key")
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+
+	if in := engine.Swagger.OpenAPI.Components.SecuritySchemes[security.ApiKeyAuth].Value.In; in == "" {
+		t.Error("missing apiKey location")
+	}
+}
+
+func TestApiKeyStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := &security.HashedKeyStore{Now: func() time.Time { return now }}
+	store.Add(security.HashKey("current"), &Client{Name: "acme"}, time.Time{})
+	store.Add(security.HashKey("expired"), &Client{Name: "acme"}, now.Add(-time.Second))
+
+	engine := swagger_gin.New(newSwagger())
+	engine.GET("/client", router.NewX(func(c *gin.Context) {
+		c.String(http.StatusOK, c.MustGet(security.Credentials).(*Client).Name)
+	}, router.Security(&security.ApiKey{Name: "X-API-Key", Store: store})))
+	engine.Init()
+
+	call := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/client", nil)
+		req.Header.Set("X-API-Key", key)
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	if w := call("current"); w.Code != http.StatusOK || w.Body.String() != "acme" {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	for _, key := range []string{"expired", "unknown", security.HashKey("current")} {
+		if w := call(key); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", key, w.Code)
+		}
+	}
+
+	if err := store.Rotate(security.HashKey("current"), security.HashKey("next"), time.Time{}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if call("current").Code != http.StatusOK || call("next").Code != http.StatusOK {
+		t.Error("expected both keys to be valid during the grace period")
+	}
+	now = now.Add(2 * time.Hour)
+	if call("current").Code != http.StatusUnauthorized || call("next").Code != http.StatusOK {
+		t.Error("expected only the new key to be valid after the grace period")
+	}
+
+	store.Revoke(security.HashKey("next"))
+	if call("next").Code != http.StatusUnauthorized {
+		t.Error("expected the revoked key to be rejected")
+	}
+}
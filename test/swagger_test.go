@@ -0,0 +1,961 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sparkle-technologies/swagger_gin"
+	"github.com/sparkle-technologies/swagger_gin/problem"
+	"github.com/sparkle-technologies/swagger_gin/router"
+	"github.com/sparkle-technologies/swagger_gin/swagger"
+	"github.com/sparkle-technologies/swagger_gin/test/order"
+)
+
+type ValidateRequest struct {
+	Name   string            `json:"name" form:"name" validate:"required,min=2,max=32,alphanum"`
+	Email  string            `json:"email" form:"email" validate:"omitempty,email"`
+	Age    int               `json:"age" form:"age" validate:"gte=18,lt=130"`
+	Color  string            `json:"color" form:"color" validate:"oneof=red green 'light blue'"`
+	Size   int               `json:"size" form:"size" validate:"oneof=1 2 3"`
+	Tags   []string          `json:"tags" form:"tags" validate:"max=5,dive,startswith=x-,len=4"`
+	Page   int               `query:"page" validate:"min=1"`
+	Prefix string            `query:"prefix" validate:"uuid"`
+	Labels map[string]string `json:"labels" form:"labels" validate:"dive,keys,alpha,max=8,endkeys,max=64"`
+	Since  string            `json:"since" form:"since" validate:"datetime=2006-01-02T15:04:05Z07:00"`
+	Day    string            `json:"day" form:"day" validate:"datetime=2006-01-02"`
+	At     string            `json:"at" form:"at" validate:"datetime=15:04"`
+	Host   string            `json:"host" form:"host" validate:"ip"`
+	Code   string            `json:"code" form:"code" validate:"alphanum,startswith=x"`
+}
+
+func buildOpenAPI(t *testing.T, register func(engine *swagger_gin.SwaGin)) *openapi3.T {
+	t.Helper()
+	engine := swagger_gin.New(newSwagger())
+	register(engine)
+	engine.Init()
+	return engine.Swagger.OpenAPI
+}
+
+func componentProperty(t *testing.T, doc *openapi3.T, component, property string) *openapi3.Schema {
+	t.Helper()
+	schemaRef := doc.Components.Schemas[component]
+	if schemaRef == nil || schemaRef.Value.Properties[property] == nil {
+		t.Fatalf("missing property %s.%s", component, property)
+	}
+	return schemaRef.Value.Properties[property].Value
+}
+
+// schemaRefOf return the component referenced by schemaRef, looking through nullable allOf wrappers
+func schemaRefOf(schemaRef *openapi3.SchemaRef) string {
+	if schemaRef.Ref == "" && schemaRef.Value != nil && len(schemaRef.Value.AllOf) == 1 {
+		return schemaRef.Value.AllOf[0].Ref
+	}
+	return schemaRef.Ref
+}
+
+func TestValidateConstraints(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.POST("/validate", router.New(func(c *gin.Context, req ValidateRequest) {
+			c.Status(http.StatusOK)
+		}))
+	})
+
+	name := componentProperty(t, doc, "ValidateRequest", "name")
+	if name.MinLength != 2 || name.MaxLength == nil || *name.MaxLength != 32 || name.Pattern != "^[a-zA-Z0-9]+$" {
+		t.Errorf("unexpected name schema %+v", name)
+	}
+	if email := componentProperty(t, doc, "ValidateRequest", "email"); email.Format != "email" {
+		t.Errorf("unexpected email format %s", email.Format)
+	}
+	age := componentProperty(t, doc, "ValidateRequest", "age")
+	if *age.Min != 18 || age.ExclusiveMin || *age.Max != 130 || !age.ExclusiveMax {
+		t.Errorf("unexpected age schema %+v", age)
+	}
+	if color := componentProperty(t, doc, "ValidateRequest", "color"); len(color.Enum) != 3 || color.Enum[2] != "light blue" {
+		t.Errorf("unexpected color enum %v", color.Enum)
+	}
+	if size := componentProperty(t, doc, "ValidateRequest", "size"); len(size.Enum) != 3 || size.Enum[0] != int64(1) {
+		t.Errorf("unexpected size enum %v", size.Enum)
+	}
+	tags := componentProperty(t, doc, "ValidateRequest", "tags")
+	if *tags.MaxItems != 5 || tags.Items.Value.Pattern != "^x-" || tags.Items.Value.MinLength != 4 {
+		t.Errorf("unexpected tags schema %+v", tags)
+	}
+
+	labels := componentProperty(t, doc, "ValidateRequest", "labels").AdditionalProperties.Schema.Value
+	if labels.Pattern != "" || labels.MaxLength == nil || *labels.MaxLength != 64 {
+		t.Errorf("unexpected labels values schema %+v", labels)
+	}
+	if since := componentProperty(t, doc, "ValidateRequest", "since"); since.Format != "date-time" {
+		t.Errorf("unexpected since format %s", since.Format)
+	}
+	if day := componentProperty(t, doc, "ValidateRequest", "day"); day.Format != "date" {
+		t.Errorf("unexpected day format %s", day.Format)
+	}
+	if at := componentProperty(t, doc, "ValidateRequest", "at"); at.Format != "" || !strings.Contains(at.Description, "15:04") {
+		t.Errorf("unexpected at schema %+v", at)
+	}
+	if host := componentProperty(t, doc, "ValidateRequest", "host"); host.Format != "" || len(host.AnyOf) != 2 ||
+		host.AnyOf[0].Value.Format != "ipv4" || host.AnyOf[1].Value.Format != "ipv6" {
+		t.Errorf("unexpected host schema %+v", host)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Validate(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if code := componentProperty(t, doc, "ValidateRequest", "code"); code.Pattern != "^[a-zA-Z0-9]+$" {
+		t.Errorf("unexpected code pattern %s", code.Pattern)
+	}
+
+	parameters := doc.Paths.Find("/validate").Post.Parameters
+	if page := parameters.GetByInAndName("query", "page"); page == nil || *page.Schema.Value.Min != 1 {
+		t.Errorf("unexpected page parameter")
+	}
+	if prefix := parameters.GetByInAndName("query", "prefix"); prefix == nil || prefix.Schema.Value.Format != "uuid" {
+		t.Errorf("unexpected prefix parameter")
+	}
+}
+
+type Level string
+
+func (Level) Enums() map[string]interface{} {
+	return map[string]interface{}{"Low": Level("low"), "High": Level("high")}
+}
+
+type Filter struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+type FilterRequest struct {
+	Filter Filter `query:"filter"`
+	Level  Level  `query:"level"`
+}
+
+func TestComponentParameters(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/filter", router.New(func(c *gin.Context, req FilterRequest) {
+			c.Status(http.StatusOK)
+		}))
+	})
+
+	parameters := doc.Paths.Find("/filter").Get.Parameters
+	for name, ref := range map[string]string{"filter": "#/components/schemas/Filter", "level": "#/components/schemas/Level"} {
+		parameter := parameters.GetByInAndName("query", name)
+		if parameter == nil || parameter.Schema.Ref != ref {
+			t.Errorf("expected %s to reference %s, got %+v", name, ref, parameter)
+		}
+	}
+	for _, parameter := range parameters {
+		if parameter.Ref != "" {
+			t.Errorf("expected inline parameters, got %s", parameter.Ref)
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Validate(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+type Audit struct {
+	CreatedBy string `json:"createdBy"`
+	UpdatedBy string `json:"updatedBy"`
+}
+
+type Pagination struct {
+	Page int `json:"page"`
+	Size int `json:"size"`
+}
+
+type EmbeddedResponse struct {
+	Audit
+	*Pagination
+	Name  string `json:"name"`
+	Owner struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"owner"`
+	Items []struct {
+		Label string `json:"label"`
+	} `json:"items"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	register := func(engine *swagger_gin.SwaGin) {
+		engine.GET("/embedded", router.NewTyped(func(c *gin.Context, req TestRequest) (EmbeddedResponse, error) {
+			return EmbeddedResponse{}, nil
+		}))
+	}
+
+	doc := buildOpenAPI(t, register)
+	for _, name := range []string{"createdBy", "updatedBy", "page", "size", "name"} {
+		componentProperty(t, doc, "EmbeddedResponse", name)
+	}
+	if owner := componentProperty(t, doc, "EmbeddedResponse", "owner"); owner.Properties["id"] == nil {
+		t.Errorf("expected inline owner schema")
+	}
+	if items := componentProperty(t, doc, "EmbeddedResponse", "items"); items.Items.Ref != "" || items.Items.Value.Properties["label"] == nil {
+		t.Errorf("expected inline items schema")
+	}
+
+	engine := swagger_gin.New(newSwagger().WithEmbeddedAllOf())
+	register(engine)
+	engine.Init()
+	schema := engine.Swagger.OpenAPI.Components.Schemas["EmbeddedResponse"].Value
+	if len(schema.AllOf) != 3 || schema.AllOf[0].Ref != "#/components/schemas/Audit" ||
+		schema.AllOf[1].Ref != "#/components/schemas/Pagination" || schema.AllOf[2].Value.Properties["name"] == nil {
+		t.Errorf("expected allOf schema, got %+v", schema)
+	}
+	if engine.Swagger.OpenAPI.Components.Schemas["Audit"] == nil {
+		t.Errorf("expected Audit component")
+	}
+}
+
+type Node struct {
+	Name     string           `json:"name" form:"name"`
+	Children []Node           `json:"children" form:"children"`
+	Parent   *Node            `json:"parent" form:"parent"`
+	Index    map[string]*Node `json:"index" form:"index"`
+}
+
+type Thread struct {
+	Comments []Comment `json:"comments"`
+}
+
+type Comment struct {
+	Replies *Thread `json:"replies"`
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.POST("/nodes", router.NewTyped(func(c *gin.Context, req Node) (Thread, error) {
+			return Thread{}, nil
+		}))
+	})
+
+	if children := componentProperty(t, doc, "Node", "children"); children.Items.Ref != "#/components/schemas/Node" {
+		t.Errorf("expected children to reference Node, got %s", children.Items.Ref)
+	}
+	if parent := doc.Components.Schemas["Node"].Value.Properties["parent"]; schemaRefOf(parent) != "#/components/schemas/Node" {
+		t.Errorf("expected parent to reference Node, got %s", schemaRefOf(parent))
+	}
+	if comments := componentProperty(t, doc, "Thread", "comments"); comments.Items.Ref != "#/components/schemas/Comment" {
+		t.Errorf("expected comments to reference Comment, got %s", comments.Items.Ref)
+	}
+	if replies := doc.Components.Schemas["Comment"].Value.Properties["replies"]; schemaRefOf(replies) != "#/components/schemas/Thread" {
+		t.Errorf("expected replies to reference Thread, got %s", schemaRefOf(replies))
+	}
+}
+
+type Response struct {
+	UserID string `json:"userId"`
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+func TestSchemaNaming(t *testing.T) {
+	register := func(engine *swagger_gin.SwaGin) {
+		engine.GET("/a", router.NewTyped(func(c *gin.Context, req TestRequest) (Response, error) {
+			return Response{}, nil
+		}))
+		engine.GET("/b", router.NewTyped(func(c *gin.Context, req TestRequest) (order.Response, error) {
+			return order.Response{}, nil
+		}))
+		engine.GET("/c", router.NewTyped(func(c *gin.Context, req TestRequest) (Page[order.Response], error) {
+			return Page[order.Response]{}, nil
+		}))
+	}
+
+	// colliding names are all qualified, whatever the order of the routes
+	for _, doc := range []*openapi3.T{
+		buildOpenAPI(t, register),
+		buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+			engine.GET("/b", router.NewTyped(func(c *gin.Context, req TestRequest) (order.Response, error) {
+				return order.Response{}, nil
+			}))
+			register(engine)
+		}),
+	} {
+		componentProperty(t, doc, "TestResponse", "userId")
+		componentProperty(t, doc, "OrderResponse", "orderId")
+		if doc.Components.Schemas["Response"] != nil {
+			t.Errorf("expected no unqualified Response component")
+		}
+		if items := componentProperty(t, doc, "PageOfResponse", "items"); items.Items.Ref != "#/components/schemas/OrderResponse" {
+			t.Errorf("expected generic items to reference OrderResponse, got %s", items.Items.Ref)
+		}
+	}
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/a", router.NewTyped(func(c *gin.Context, req TestRequest) (Response, error) {
+			return Response{}, nil
+		}))
+	})
+	componentProperty(t, doc, "Response", "userId")
+
+	doc = buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.Swagger.WithSchemaNaming(swagger.QualifiedName)
+		register(engine)
+	})
+	componentProperty(t, doc, "TestResponse", "userId")
+	componentProperty(t, doc, "OrderResponse", "orderId")
+	componentProperty(t, doc, "TestPageOfOrderResponse", "total")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected colliding names to panic")
+		}
+	}()
+	buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.Swagger.WithStrictSchemaNames()
+		register(engine)
+	})
+}
+
+type UUID [16]byte
+
+type Money struct {
+	value int64
+}
+
+func (Money) OpenAPISchema() *openapi3.Schema {
+	return openapi3.NewStringSchema().WithPattern(`^-?[0-9]+\.[0-9]{2}$`)
+}
+
+type Status string
+
+type Codes []string
+
+type CustomTypesResponse struct {
+	ID       UUID            `json:"id"`
+	Price    Money           `json:"price" description:"price in EUR"`
+	Discount *Money          `json:"discount"`
+	Timeout  time.Duration   `json:"timeout"`
+	Raw      json.RawMessage `json:"raw"`
+	Status   Status          `json:"status"`
+	Prices   []Money         `json:"prices"`
+	Nickname sql.NullString  `json:"nickname"`
+	Visits   sql.NullInt64   `json:"visits"`
+	SeenAt   sql.NullTime    `json:"seenAt"`
+	Codes    Codes           `json:"codes" validate:"dive,max=3"`
+	Aliases  Codes           `json:"aliases"`
+}
+
+func TestCustomTypeSchemas(t *testing.T) {
+	codes := openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema())
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.Swagger.WithTypeSchema(reflect.TypeOf(UUID{}), openapi3.NewUUIDSchema())
+		engine.Swagger.WithTypeSchema(reflect.TypeOf(Codes{}), codes)
+		engine.GET("/custom", router.NewTyped(func(c *gin.Context, req TestRequest) (CustomTypesResponse, error) {
+			return CustomTypesResponse{}, nil
+		}))
+	})
+
+	if id := componentProperty(t, doc, "CustomTypesResponse", "id"); id.Format != "uuid" {
+		t.Errorf("expected uuid format, got %+v", id)
+	}
+	price := componentProperty(t, doc, "CustomTypesResponse", "price")
+	if !price.Type.Is(openapi3.TypeString) || price.Pattern == "" || price.Description != "price in EUR" {
+		t.Errorf("expected provided schema, got %+v", price)
+	}
+	if discount := componentProperty(t, doc, "CustomTypesResponse", "discount"); !discount.Type.Is(openapi3.TypeString) {
+		t.Errorf("expected provided schema for pointer, got %+v", discount)
+	}
+	if timeout := componentProperty(t, doc, "CustomTypesResponse", "timeout"); !timeout.Type.Is(openapi3.TypeInteger) {
+		t.Errorf("expected integer duration, got %+v", timeout)
+	}
+	if raw := componentProperty(t, doc, "CustomTypesResponse", "raw"); raw.Type != nil {
+		t.Errorf("expected any value for raw message, got %+v", raw)
+	}
+	if status := componentProperty(t, doc, "CustomTypesResponse", "status"); !status.Type.Is(openapi3.TypeString) {
+		t.Errorf("expected string status, got %+v", status)
+	}
+	if prices := componentProperty(t, doc, "CustomTypesResponse", "prices"); !prices.Items.Value.Type.Is(openapi3.TypeString) {
+		t.Errorf("expected provided item schema, got %+v", prices.Items.Value)
+	}
+	for property, type_ := range map[string]string{"nickname": openapi3.TypeString, "visits": openapi3.TypeInteger, "seenAt": openapi3.TypeString} {
+		if null := componentProperty(t, doc, "CustomTypesResponse", property); !null.Type.Is(type_) || !null.Nullable {
+			t.Errorf("expected nullable %s for %s, got %+v", type_, property, null)
+		}
+	}
+	// field constraints don't leak into the registered schema or other fields of the type
+	if items := componentProperty(t, doc, "CustomTypesResponse", "codes").Items.Value; items.MaxLength == nil || *items.MaxLength != 3 {
+		t.Errorf("expected constrained code items, got %+v", items)
+	}
+	if items := componentProperty(t, doc, "CustomTypesResponse", "aliases").Items.Value; items.MaxLength != nil {
+		t.Errorf("expected unconstrained alias items, got %+v", items)
+	}
+	if codes.Items.Value.MaxLength != nil {
+		t.Errorf("expected the registered schema to be left as is")
+	}
+	if doc.Components.Schemas["Money"] != nil {
+		t.Errorf("expected provided schemas to be inlined")
+	}
+}
+
+type PaymentMethod interface {
+	Kind() string
+}
+
+type Card struct {
+	Type   string `json:"type" form:"type"`
+	Number string `json:"number" form:"number" validate:"required"`
+}
+
+func (Card) Kind() string {
+	return "card"
+}
+
+type Iban struct {
+	Type string `json:"type" form:"type"`
+	Iban string `json:"iban" form:"iban"`
+}
+
+func (*Iban) Kind() string {
+	return "iban"
+}
+
+type PaymentRequest struct {
+	Amount   int64                    `json:"amount" form:"amount"`
+	Method   PaymentMethod            `json:"method" form:"method"`
+	Fallback []PaymentMethod          `json:"fallback" form:"fallback"`
+	ByLabel  map[string]PaymentMethod `json:"byLabel" form:"byLabel"`
+}
+
+func init() {
+	router.RegisterPolymorphic[PaymentMethod]("type", map[string]PaymentMethod{
+		"card": Card{},
+		"iban": &Iban{},
+	})
+}
+
+func TestPolymorphicSchemas(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.POST("/payments", router.NewTyped(func(c *gin.Context, req PaymentRequest) (PaymentMethod, error) {
+			return req.Method, nil
+		}))
+	})
+
+	method := doc.Components.Schemas["PaymentMethod"]
+	if method == nil || len(method.Value.OneOf) != 2 {
+		t.Fatalf("expected oneOf component, got %+v", method)
+	}
+	discriminator := method.Value.Discriminator
+	if discriminator == nil || discriminator.PropertyName != "type" ||
+		discriminator.Mapping["card"] != "#/components/schemas/Card" ||
+		discriminator.Mapping["iban"] != "#/components/schemas/Iban" {
+		t.Errorf("unexpected discriminator %+v", discriminator)
+	}
+	// Card requires only validated fields in requests, so requests get their own oneOf component
+	if ref := schemaRefOf(doc.Components.Schemas["PaymentRequest"].Value.Properties["method"]); ref != "#/components/schemas/PaymentMethodRequest" {
+		t.Errorf("expected method to reference the request oneOf component, got %s", ref)
+	}
+	if items := componentProperty(t, doc, "PaymentRequest", "fallback").Items; items.Ref != "#/components/schemas/PaymentMethodRequest" {
+		t.Errorf("expected fallback items to reference the request oneOf component, got %s", items.Ref)
+	}
+	if values := componentProperty(t, doc, "PaymentRequest", "byLabel").AdditionalProperties.Schema; values.Ref != "#/components/schemas/PaymentMethodRequest" {
+		t.Errorf("expected byLabel values to reference the request oneOf component, got %s", values.Ref)
+	}
+	if mapping := doc.Components.Schemas["PaymentMethodRequest"].Value.Discriminator.Mapping; mapping["card"] != "#/components/schemas/CardRequest" ||
+		mapping["iban"] != "#/components/schemas/IbanRequest" {
+		t.Errorf("unexpected request discriminator mapping %v", mapping)
+	}
+	response := doc.Paths.Find("/payments").Post.Responses.Value("200").Value.Content.Get("application/json")
+	if response.Schema.Ref != "#/components/schemas/PaymentMethod" {
+		t.Errorf("expected response to reference the oneOf component, got %s", response.Schema.Ref)
+	}
+}
+
+type OptionalFields struct {
+	ID       string            `json:"id" form:"id"`
+	Name     *string           `json:"name" form:"name"`
+	Nickname string            `json:"nickname,omitempty" form:"nickname"`
+	Tags     []string          `json:"tags" form:"tags"`
+	Labels   map[string]string `json:"labels,omitempty" form:"labels"`
+	Parent   *Response         `json:"parent" form:"parent" validate:"required"`
+	Secret   string            `json:"-" form:"-"`
+	Internal string            `json:"-," form:"internal"`
+	Untagged int               `form:"untagged"`
+}
+
+func TestOptionalFields(t *testing.T) {
+	requestDoc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.POST("/optional", router.New(func(c *gin.Context, req OptionalFields) {
+			c.Status(http.StatusOK)
+		}))
+	})
+	responseDoc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/optional", router.NewTyped(func(c *gin.Context, req TestRequest) (OptionalFields, error) {
+			return OptionalFields{}, nil
+		}))
+	})
+
+	request := requestDoc.Components.Schemas["OptionalFields"].Value
+	if !reflect.DeepEqual(request.Required, []string{"parent"}) {
+		t.Errorf("expected only validated fields to be required in requests, got %v", request.Required)
+	}
+	if _, ok := request.Properties["Secret"]; ok {
+		t.Errorf("expected form:\"-\" fields to be skipped")
+	}
+
+	response := responseDoc.Components.Schemas["OptionalFields"].Value
+	if !reflect.DeepEqual(response.Required, []string{"id", "tags", "-", "Untagged"}) {
+		t.Errorf("expected fields always written by encoding/json to be required, got %v", response.Required)
+	}
+	if _, ok := response.Properties["Secret"]; ok {
+		t.Errorf("expected json:\"-\" fields to be skipped")
+	}
+
+	for _, schema := range []*openapi3.Schema{request, response} {
+		if !schema.Properties["tags"].Value.Nullable || schema.Properties["id"].Value.Nullable {
+			t.Errorf("expected only nil-able fields to be nullable")
+		}
+		if parent := schema.Properties["parent"]; !parent.Value.Nullable || schemaRefOf(parent) != "#/components/schemas/Response" {
+			t.Errorf("expected parent to be a nullable ref, got %+v", parent.Value)
+		}
+	}
+	if !response.Properties["name"].Value.Nullable || response.Properties["labels"].Value.Nullable {
+		t.Errorf("expected pointers to be nullable and omitempty fields not")
+	}
+}
+
+type ExampleRequest struct {
+	Limit   int       `query:"limit" default:"10" examples:"small=5;large=100"`
+	Enabled bool      `query:"enabled" default:"true"`
+	Ratio   float64   `json:"ratio" form:"ratio" default:"0.5" example:"0.75"`
+	Tags    []string  `json:"tags" form:"tags" default:"a,b" example:"x,y"`
+	Sizes   []int     `json:"sizes" form:"sizes" example:"[1,2]"`
+	Name    string    `json:"name" form:"name" examples:"short=Bo;long=Bartholomew"`
+	Since   time.Time `json:"since" form:"since" example:"2024-01-02T15:04:05Z" description:"start of the range"`
+}
+
+func (ExampleRequest) Examples() map[string]interface{} {
+	return map[string]interface{}{
+		"minimal": ExampleRequest{Name: "Bo"},
+	}
+}
+
+func TestExampleValues(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.POST("/examples", router.New(func(c *gin.Context, req ExampleRequest) {
+			c.Status(http.StatusOK)
+		}))
+	})
+
+	operation := doc.Paths.Find("/examples").Post
+	limit := operation.Parameters.GetByInAndName("query", "limit")
+	if limit.Schema.Value.Default != int64(10) {
+		t.Errorf("expected typed default, got %#v", limit.Schema.Value.Default)
+	}
+	if len(limit.Examples) != 2 || limit.Examples["large"].Value.Value != int64(100) {
+		t.Errorf("expected named examples, got %+v", limit.Examples)
+	}
+	if enabled := operation.Parameters.GetByInAndName("query", "enabled"); enabled.Schema.Value.Default != true {
+		t.Errorf("expected boolean default, got %#v", enabled.Schema.Value.Default)
+	}
+
+	ratio := componentProperty(t, doc, "ExampleRequest", "ratio")
+	if ratio.Default != 0.5 || ratio.Example != 0.75 {
+		t.Errorf("unexpected ratio default %#v and example %#v", ratio.Default, ratio.Example)
+	}
+	tags := componentProperty(t, doc, "ExampleRequest", "tags")
+	if !reflect.DeepEqual(tags.Default, []interface{}{"a", "b"}) || !reflect.DeepEqual(tags.Example, []interface{}{"x", "y"}) {
+		t.Errorf("unexpected tags default %#v and example %#v", tags.Default, tags.Example)
+	}
+	if sizes := componentProperty(t, doc, "ExampleRequest", "sizes"); !reflect.DeepEqual(sizes.Example, []interface{}{1.0, 2.0}) {
+		t.Errorf("unexpected sizes example %#v", sizes.Example)
+	}
+	if name := componentProperty(t, doc, "ExampleRequest", "name"); name.Example != "Bo" {
+		t.Errorf("expected the first named example, got %#v", name.Example)
+	}
+	if since := componentProperty(t, doc, "ExampleRequest", "since"); since.Example != "2024-01-02T15:04:05Z" || since.Description != "start of the range" {
+		t.Errorf("unexpected since example %#v and description %s", since.Example, since.Description)
+	}
+
+	mediaType := operation.RequestBody.Value.Content.Get("application/json")
+	if example := mediaType.Examples["minimal"]; example == nil || example.Value.Value.(ExampleRequest).Name != "Bo" {
+		t.Errorf("expected media type examples, got %+v", mediaType.Examples)
+	}
+}
+
+type Account struct {
+	ID        string    `json:"id" form:"id" openapi:"readonly"`
+	Email     string    `json:"email" form:"email"`
+	Password  string    `json:"password" form:"password" openapi:"writeonly"`
+	Username  string    `json:"username" form:"username" openapi:"deprecated"`
+	Owner     *Response `json:"owner" form:"owner" openapi:"readonly"`
+	CreatedAt time.Time `json:"createdAt" form:"createdAt" openapi:"readonly"`
+}
+
+func TestFieldMarkers(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.POST("/accounts", router.NewTyped(func(c *gin.Context, req Account) (Account, error) {
+			return req, nil
+		}))
+	})
+
+	if id := componentProperty(t, doc, "Account", "id"); !id.ReadOnly || id.WriteOnly {
+		t.Errorf("expected id to be readOnly, got %+v", id)
+	}
+	if createdAt := componentProperty(t, doc, "Account", "createdAt"); !createdAt.ReadOnly {
+		t.Errorf("expected createdAt to be readOnly, got %+v", createdAt)
+	}
+	if password := componentProperty(t, doc, "Account", "password"); !password.WriteOnly {
+		t.Errorf("expected password to be writeOnly, got %+v", password)
+	}
+	if username := componentProperty(t, doc, "Account", "username"); !username.Deprecated {
+		t.Errorf("expected username to be deprecated, got %+v", username)
+	}
+	owner := doc.Components.Schemas["Account"].Value.Properties["owner"]
+	if !owner.Value.ReadOnly || schemaRefOf(owner) != "#/components/schemas/Response" {
+		t.Errorf("expected owner to be a readOnly ref, got %+v", owner.Value)
+	}
+}
+
+type UpdateItemRequest struct {
+	ID      string `uri:"id" json:"id"`
+	Version int    `query:"version" form:"version"`
+	Trace   string `header:"X-Trace"`
+	Name    string `json:"name" form:"title"`
+	Note    string `json:"note"`
+	Upload  string `json:"-" form:"upload"`
+}
+
+func TestRequestBodyFields(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.PUT("/items/:id", router.New(func(c *gin.Context, req UpdateItemRequest) {
+			c.Status(http.StatusOK)
+		}, router.RequestContentTypes(binding.MIMEJSON, binding.MIMEPOSTForm)))
+		engine.POST("/items/:id/touch", router.New(func(c *gin.Context, req TestRequest) {
+			c.Status(http.StatusOK)
+		}))
+	})
+
+	operation := doc.Paths.Find("/items/{id}").Put
+	if len(operation.Parameters) != 3 {
+		t.Errorf("expected id, version and X-Trace parameters, got %d", len(operation.Parameters))
+	}
+
+	schema := doc.Components.Schemas["UpdateItemRequest"].Value
+	if len(schema.Properties) != 2 || schema.Properties["name"] == nil || schema.Properties["note"] == nil {
+		t.Errorf("expected the JSON body to hold the json fields only, got %v", sortedProperties(schema))
+	}
+
+	form := operation.RequestBody.Value.Content.Get(binding.MIMEPOSTForm).Schema
+	if form.Ref != "" || len(form.Value.Properties) != 3 ||
+		form.Value.Properties["title"] == nil || form.Value.Properties["Note"] == nil || form.Value.Properties["upload"] == nil {
+		t.Errorf("expected the form body to follow the form tags, got %v", sortedProperties(form.Value))
+	}
+	if json := operation.RequestBody.Value.Content.Get(binding.MIMEJSON).Schema; json.Ref != "#/components/schemas/UpdateItemRequest" {
+		t.Errorf("expected the JSON body to reference the component, got %s", json.Ref)
+	}
+
+	if touch := doc.Paths.Find("/items/{id}/touch").Post; touch.RequestBody != nil {
+		t.Errorf("expected no request body for models bound from parameters only")
+	}
+}
+
+type Shared struct {
+	ID   string `query:"id" json:"id"`
+	Name string `json:"name" validate:"required"`
+	Note string `json:"note,omitempty"`
+}
+
+type SharedList struct {
+	Items []Shared `json:"items"`
+}
+
+type Same struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestSharedRequestResponseComponents(t *testing.T) {
+	register := func(responseFirst bool) func(engine *swagger_gin.SwaGin) {
+		routes := []func(engine *swagger_gin.SwaGin){
+			func(engine *swagger_gin.SwaGin) {
+				engine.GET("/a", router.NewTyped(func(c *gin.Context, req TestRequest) (SharedList, error) {
+					return SharedList{}, nil
+				}))
+			},
+			func(engine *swagger_gin.SwaGin) {
+				engine.POST("/b", router.New(func(c *gin.Context, req Shared) {
+					c.Status(http.StatusOK)
+				}))
+			},
+		}
+		return func(engine *swagger_gin.SwaGin) {
+			if !responseFirst {
+				routes[0], routes[1] = routes[1], routes[0]
+			}
+			for _, route := range routes {
+				route(engine)
+			}
+			engine.PUT("/same", router.NewTyped(func(c *gin.Context, req Same) (Same, error) {
+				return req, nil
+			}))
+		}
+	}
+
+	for _, responseFirst := range []bool{true, false} {
+		doc := buildOpenAPI(t, register(responseFirst))
+
+		response := doc.Components.Schemas["Shared"].Value
+		if got := sortedProperties(response); len(got) != 3 || len(response.Required) != 2 {
+			t.Errorf("expected the response component to keep id, got %v required %v", got, response.Required)
+		}
+		if items := componentProperty(t, doc, "SharedList", "items").Items; items.Ref != "#/components/schemas/Shared" {
+			t.Errorf("expected response items to reference Shared, got %s", items.Ref)
+		}
+
+		request := doc.Components.Schemas["SharedRequest"]
+		if request == nil || request.Value.Title != "SharedRequest" {
+			t.Fatalf("expected a SharedRequest component, got %+v", request)
+		}
+		if got := sortedProperties(request.Value); len(got) != 2 || len(request.Value.Required) != 1 {
+			t.Errorf("expected the request component to hold the body fields, got %v required %v", got, request.Value.Required)
+		}
+		body := doc.Paths.Find("/b").Post.RequestBody.Value.Content.Get(binding.MIMEJSON).Schema
+		if body.Ref != "#/components/schemas/SharedRequest" {
+			t.Errorf("expected the request body to reference SharedRequest, got %s", body.Ref)
+		}
+
+		// identical request and response schemas share one component
+		if doc.Components.Schemas["SameRequest"] != nil {
+			t.Errorf("expected identical schemas to share the Same component")
+		}
+		if body := doc.Paths.Find("/same").Put.RequestBody.Value.Content.Get(binding.MIMEJSON).Schema; body.Ref != "#/components/schemas/Same" {
+			t.Errorf("expected the request body to reference Same, got %s", body.Ref)
+		}
+	}
+}
+
+func sortedProperties(schema *openapi3.Schema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestResponseShapes(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/slice", router.NewTyped(func(c *gin.Context, req TestRequest) ([]Response, error) {
+			return nil, nil
+		}))
+		engine.GET("/map", router.NewTyped(func(c *gin.Context, req TestRequest) (map[string]Response, error) {
+			return nil, nil
+		}))
+		engine.GET("/string", router.NewTyped(func(c *gin.Context, req TestRequest) (string, error) {
+			return "", nil
+		}))
+		engine.GET("/pointer", router.NewTyped(func(c *gin.Context, req TestRequest) (*Response, error) {
+			return nil, nil
+		}))
+		engine.GET("/generic", router.NewTyped(func(c *gin.Context, req TestRequest) ([]Page[Response], error) {
+			return nil, nil
+		}))
+		engine.POST("/batch", router.New(func(c *gin.Context, req []ValidateRequest) {
+			c.Status(http.StatusOK)
+		}))
+	})
+
+	schemaOf := func(path string) *openapi3.SchemaRef {
+		t.Helper()
+		operation := doc.Paths.Find(path).Get
+		if operation == nil {
+			operation = doc.Paths.Find(path).Post
+		}
+		return operation.Responses.Value("200").Value.Content.Get("application/json").Schema
+	}
+
+	if slice := schemaOf("/slice"); !slice.Value.Type.Is(openapi3.TypeArray) || slice.Value.Items.Ref != "#/components/schemas/Response" {
+		t.Errorf("expected an array of Response, got %+v", slice.Value)
+	}
+	if m := schemaOf("/map"); !m.Value.Type.Is(openapi3.TypeObject) || m.Value.AdditionalProperties.Schema.Ref != "#/components/schemas/Response" {
+		t.Errorf("expected a map of Response, got %+v", m.Value)
+	}
+	if str := schemaOf("/string"); str.Ref != "" || !str.Value.Type.Is(openapi3.TypeString) {
+		t.Errorf("expected an inline string, got %+v", str)
+	}
+	if pointer := schemaOf("/pointer"); pointer.Ref != "#/components/schemas/Response" {
+		t.Errorf("expected pointers to reference their element, got %s", pointer.Ref)
+	}
+	if generic := schemaOf("/generic"); generic.Value.Items.Ref != "#/components/schemas/PageOfResponse" {
+		t.Errorf("expected an array of PageOfResponse, got %s", generic.Value.Items.Ref)
+	}
+
+	batch := doc.Paths.Find("/batch").Post
+	if len(batch.Parameters) != 0 {
+		t.Errorf("expected no parameters for slice models, got %d", len(batch.Parameters))
+	}
+	if body := batch.RequestBody.Value.Content.Get("application/json").Schema; body.Value.Items.Ref != "#/components/schemas/ValidateRequest" {
+		t.Errorf("expected an array of ValidateRequest, got %+v", body.Value)
+	}
+	for name := range doc.Components.Schemas {
+		if name == "" {
+			t.Errorf("expected no unnamed components")
+		}
+	}
+}
+
+func TestResponseContent(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/report", router.New(func(c *gin.Context, req TestRequest) {
+			c.Status(http.StatusOK)
+		}, router.Responses(router.Response{
+			"200": router.ResponseItem{
+				Description: "Report",
+				Content: map[string]router.MediaType{
+					binding.MIMEJSON: {Model: []Response{}},
+					"text/csv": {
+						Model:    "",
+						Examples: map[string]interface{}{"one row": "userId\n42\n"},
+					},
+				},
+			},
+			"204": router.ResponseItem{Description: "Empty report"},
+			"default": router.ResponseItem{
+				Description: "Unexpected error",
+				Content: map[string]router.MediaType{
+					problem.MIMEProblemJSON: {Model: problem.Problem{}},
+				},
+			},
+		})))
+	})
+
+	responses := doc.Paths.Find("/report").Get.Responses
+	ok := responses.Value("200").Value
+	if items := ok.Content.Get(binding.MIMEJSON).Schema.Value.Items; items.Ref != "#/components/schemas/Response" {
+		t.Errorf("expected JSON to be an array of Response, got %s", items.Ref)
+	}
+	csv := ok.Content.Get("text/csv")
+	if !csv.Schema.Value.Type.Is(openapi3.TypeString) || csv.Examples["one row"].Value.Value != "userId\n42\n" {
+		t.Errorf("expected a string CSV with examples, got %+v", csv)
+	}
+	if empty := responses.Value("204").Value; *empty.Description != "Empty report" || len(empty.Content) != 0 {
+		t.Errorf("expected a response without content, got %+v", empty)
+	}
+	defaultResponse := responses.Default()
+	if defaultResponse == nil || *defaultResponse.Value.Description != "Unexpected error" ||
+		defaultResponse.Value.Content.Get(problem.MIMEProblemJSON).Schema.Ref != "#/components/schemas/Problem" {
+		t.Errorf("expected a problem default response, got %+v", defaultResponse)
+	}
+}
+
+type RateLimitHeaders struct {
+	Remaining int       `header:"X-RateLimit-Remaining" description:"requests left in the window" validate:"required,min=0"`
+	ETag      string    `header:"ETag,omitempty" example:"\"v1\""`
+	Location  *string   `header:"Location"`
+	Expires   time.Time `header:"Expires,omitempty"`
+	Links     []string  `header:"Link"`
+	Ignored   string
+}
+
+func TestResponseHeaders(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/limited", router.New(func(c *gin.Context, req TestRequest) {
+			c.Status(http.StatusOK)
+		}, router.Responses(router.Response{
+			"200": router.ResponseItem{
+				Description: "OK",
+				Model:       Response{},
+				HeaderModel: RateLimitHeaders{},
+				Headers: openapi3.Headers{
+					"X-Extra": &openapi3.HeaderRef{Value: &openapi3.Header{}},
+				},
+			},
+		})))
+	})
+
+	headers := doc.Paths.Find("/limited").Get.Responses.Value("200").Value.Headers
+	if len(headers) != 6 {
+		t.Errorf("expected the header fields and the raw header, got %d", len(headers))
+	}
+	remaining := headers["X-RateLimit-Remaining"].Value
+	if !remaining.Required || remaining.Description != "requests left in the window" ||
+		!remaining.Schema.Value.Type.Is(openapi3.TypeInteger) || remaining.Schema.Value.Min == nil {
+		t.Errorf("unexpected X-RateLimit-Remaining %+v", remaining)
+	}
+	if etag := headers["ETag"].Value; etag.Schema.Value.Example != `"v1"` {
+		t.Errorf("expected an ETag example, got %+v", etag.Schema.Value)
+	}
+	if expires := headers["Expires"].Value; expires.Schema.Value.Format != "" {
+		t.Errorf("expected an HTTP date, got format %s", expires.Schema.Value.Format)
+	}
+	if links := headers["Link"].Value; !links.Schema.Value.Type.Is(openapi3.TypeArray) {
+		t.Errorf("expected Link to be an array, got %+v", links.Schema.Value)
+	}
+}
+
+type UserFileRequest struct {
+	UserID  int    `uri:"user_id"`
+	Stale   string `uri:"stale"`
+	Version string `query:"version"`
+}
+
+func TestPathParams(t *testing.T) {
+	doc := buildOpenAPI(t, func(engine *swagger_gin.SwaGin) {
+		engine.GET("/users/:user_id/files/*filepath", router.New(func(c *gin.Context, req UserFileRequest) {
+			c.Status(http.StatusOK)
+		}))
+	})
+
+	pathItem := doc.Paths.Value("/users/{user_id}/files/{filepath}")
+	if pathItem == nil {
+		t.Fatalf("expected converted path, got %v", doc.Paths.InMatchingOrder())
+	}
+	parameters := pathItem.Get.Parameters
+	userID := parameters.GetByInAndName(openapi3.ParameterInPath, "user_id")
+	if userID == nil || !userID.Required || !userID.Schema.Value.Type.Is(openapi3.TypeInteger) {
+		t.Errorf("expected the uri field to document user_id, got %+v", userID)
+	}
+	filepath := parameters.GetByInAndName(openapi3.ParameterInPath, "filepath")
+	if filepath == nil || !filepath.Required || !filepath.Schema.Value.Type.Is(openapi3.TypeString) {
+		t.Errorf("expected a generated filepath parameter, got %+v", filepath)
+	}
+	if parameters.GetByInAndName(openapi3.ParameterInPath, "stale") != nil {
+		t.Errorf("expected uri fields without a path param to be dropped")
+	}
+	if parameters.GetByInAndName(openapi3.ParameterInQuery, "version") == nil {
+		t.Errorf("expected query parameters to be kept")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected StrictPathParams to panic")
+		}
+	}()
+	engine := swagger_gin.New(newSwagger().WithStrictPathParams())
+	engine.GET("/users/:user_id/files/*filepath", router.New(func(c *gin.Context, req UserFileRequest) {
+		c.Status(http.StatusOK)
+	}))
+	engine.Init()
+}